@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jbweber/gringotts-bot/internal/catalog"
+	"github.com/jbweber/gringotts-bot/internal/config"
+)
+
+// runCatalog loads item metadata dumps into the item catalog shared by every
+// server.
+func runCatalog(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("catalog", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "usage: gringotts-bot catalog [flags] <file>...")
+		_, _ = fmt.Fprintln(fs.Output(), "files are CSV with a header row or a JSON array of items")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("at least one file is required")
+	}
+
+	cfg, err := loadConfig(cf, false)
+	if err != nil {
+		return err
+	}
+
+	g, closeDB, err := openGringotts(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer closeDB()
+
+	for _, name := range fs.Args() {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		items, err := catalog.Parse(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if err := g.ImportCatalog(context.Background(), items); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		_, _ = fmt.Fprintf(out, "loaded %d items from %s\n", len(items), name)
+	}
+
+	return nil
+}
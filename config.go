@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/config"
+)
+
+// runConfig reports every problem with the configuration with "check".
+func runConfig(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "usage: gringotts-bot config check [flags]")
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 || args[0] != "check" {
+		fs.Usage()
+		return errors.New("expected check")
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := cf.Load()
+	if err != nil {
+		return err
+	}
+
+	err = cfg.ValidateDiscord()
+	if err == nil {
+		_, _ = fmt.Fprintln(out, "configuration ok")
+		return nil
+	}
+
+	problems := strings.Split(err.Error(), "\n")
+	for _, p := range problems {
+		_, _ = fmt.Fprintf(out, "- %s\n", p)
+	}
+
+	return fmt.Errorf("%d configuration problem(s) found", len(problems))
+}
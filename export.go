@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/config"
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/inventory"
+)
+
+// runExport writes the current inventory of every bank character, or just one.
+// The CSV and exporter output can be loaded again with the import command, the
+// JSON output is meant for other tools.
+func runExport(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	guildFlag := addGuildFlag(fs)
+	charName := fs.String("character", "", "only export this character, as Name or Name-Realm")
+	format := fs.String("format", "csv", "output format, csv (TSM style columns), exporter (GringottsExporter payloads, one line per character) or json (plain JSON, export only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != "csv" && *format != "exporter" && *format != "json" {
+		return fmt.Errorf("unknown format %q, expected csv, exporter or json", *format)
+	}
+
+	cfg, err := loadConfig(cf, false)
+	if err != nil {
+		return err
+	}
+
+	guildID, err := dataGuild(cfg, *guildFlag)
+	if err != nil {
+		return err
+	}
+
+	g, closeDB, err := openGringotts(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer closeDB()
+
+	locations, err := g.FindItemLocations(context.Background(), guildID, "", "")
+	if err != nil {
+		return err
+	}
+
+	if *format == "csv" {
+		w := csv.NewWriter(out)
+		_ = w.Write([]string{"itemString", "itemName", "quantity", "player", "realm", "faction", "location"})
+		for _, l := range locations {
+			if !exportOwner(l.Owner, *charName) {
+				continue
+			}
+
+			_ = w.Write([]string{"i:" + l.ID, l.Name, fmt.Sprint(l.Count), l.Owner.Name, l.Owner.Realm, l.Owner.Faction, l.Location})
+		}
+
+		w.Flush()
+
+		return w.Error()
+	}
+
+	byOwner := map[database.Owner]*inventory.InventoryData{}
+	results := []*inventory.InventoryData{}
+	for _, l := range locations {
+		if !exportOwner(l.Owner, *charName) {
+			continue
+		}
+
+		d, ok := byOwner[l.Owner]
+		if !ok {
+			d = &inventory.InventoryData{
+				Version:       inventory.CurrentVersion,
+				CharName:      l.Owner.Name,
+				Realm:         l.Owner.Realm,
+				Faction:       l.Owner.Faction,
+				Flavor:        l.Owner.Flavor,
+				ItemCounts:    map[string]int{},
+				ItemNames:     map[string]string{},
+				ItemLocations: map[string]map[string]int{},
+			}
+			byOwner[l.Owner] = d
+			results = append(results, d)
+		}
+
+		if d.ItemLocations[l.Location] == nil {
+			d.ItemLocations[l.Location] = map[string]int{}
+		}
+
+		d.ItemCounts[l.ID] += l.Count
+		d.ItemNames[l.ID] = l.Name
+		d.ItemLocations[l.Location][l.ID] += l.Count
+	}
+
+	if *format == "exporter" {
+		for _, d := range results {
+			payload, err := inventory.EncodeInventoryData(d)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintln(out, payload); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	e := json.NewEncoder(out)
+	e.SetIndent("", "  ")
+
+	return e.Encode(results)
+}
+
+// exportOwner reports whether owner is selected by the -character flag.
+func exportOwner(owner database.Owner, charName string) bool {
+	return charName == "" || strings.EqualFold(owner.Name, charName) || strings.EqualFold(owner.String(), charName)
+}
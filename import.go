@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jbweber/gringotts-bot/internal/config"
+	"github.com/jbweber/gringotts-bot/internal/inventory"
+)
+
+// runImport loads inventory files, such as a GringottsExporter.lua
+// SavedVariables file, straight into the database without going through
+// Discord.
+func runImport(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	guildFlag := addGuildFlag(fs)
+	charName := fs.String("character", "", "character the inventory belongs to, for formats that do not record it")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "usage: gringotts-bot import [-character name] <file>...")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("at least one file is required")
+	}
+
+	cfg, err := loadConfig(cf, false)
+	if err != nil {
+		return err
+	}
+
+	guildID, err := dataGuild(cfg, *guildFlag)
+	if err != nil {
+		return err
+	}
+
+	g, closeDB, err := openGringotts(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer closeDB()
+
+	for _, name := range fs.Args() {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		results, err := inventory.Import(b, inventory.Options{CharName: *charName})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		for _, r := range results {
+			err = g.UpdateItemLocations(context.Background(), guildID, r.Owner(), r.Locations())
+			if err != nil {
+				return err
+			}
+
+			err = g.UpdateItems(context.Background(), r.ItemNames)
+			if err != nil {
+				return err
+			}
+
+			err = g.UpdateItemQualities(context.Background(), r.ItemQualities)
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(out, "loaded %d items for %s from %s\n", len(r.ItemCounts), r.Owner(), name)
+		}
+	}
+
+	return nil
+}
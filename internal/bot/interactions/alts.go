@@ -0,0 +1,203 @@
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+var altSubCommandGroup = &discordgo.ApplicationCommandOption{
+	Name:        "alt",
+	Description: "manage the registered bank characters",
+	Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        "add",
+			Description: "register a bank character and a user responsible for it",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "name of the bank character",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "realm",
+					Description: "realm of the bank character",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "faction",
+					Description: "faction of the bank character",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "alliance", Value: database.FactionAlliance},
+						{Name: "horde", Value: database.FactionHorde},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "user allowed to upload inventory for the character",
+					Required:    true,
+				},
+				flavorOption,
+			},
+		},
+		{
+			Name:        "remove",
+			Description: "remove a bank character, or only a user responsible for it",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "name of the bank character",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "realm",
+					Description: "realm of the bank character",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "only remove this user from the character",
+				},
+				flavorOption,
+			},
+		},
+		{
+			Name:        "list",
+			Description: "list the registered bank characters",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+	},
+}
+
+// flavorOption picks the game flavor of a bank character, needed to tell apart
+// characters with the same name and realm on different flavors.
+var flavorOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "flavor",
+	Description: "game flavor of the bank character",
+	Choices:     flavorChoices(),
+}
+
+func flavorChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, f := range database.Flavors {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: f, Value: f})
+	}
+
+	return choices
+}
+
+func (h *Handler) Alts(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sub := i.ApplicationCommandData().Options[0].Options[0]
+	switch sub.Name {
+	case "add", "remove":
+		content, owner, err := h.changeAlt(i.GuildID, sub)
+		h.recordAudit(i, owner, err)
+		if err != nil {
+			doFailedInteraction(s, i, err.Error())
+			return
+		}
+
+		respondEphemeral(s, i, content)
+	case "list":
+		characters, err := h.gringotts.ListBankCharacters(context.Background(), i.GuildID)
+		if err != nil {
+			doFailedInteraction(s, i, fmt.Sprintf("unable to list bank characters: %v", err))
+			return
+		}
+
+		lines := []string{}
+		for _, c := range characters {
+			var users []string
+			for _, u := range c.UserIDs {
+				users = append(users, fmt.Sprintf("<@%s>", u))
+			}
+			if len(users) == 0 {
+				users = append(users, "no users")
+			}
+
+			details := c.Faction
+			if c.Flavor != "" {
+				details += ", " + c.Flavor
+			}
+
+			lines = append(lines, fmt.Sprintf("%s (%s): %s", c.Owner(), details, strings.Join(users, ", ")))
+		}
+
+		if len(lines) == 0 {
+			lines = append(lines, "no bank characters registered")
+		}
+
+		respondEphemeral(s, i, truncateLines(lines, maxContentLength))
+	default:
+		doFailedInteraction(s, i, fmt.Sprintf("unknown subcommand %s", sub.Name))
+	}
+}
+
+// changeAlt registers or removes a bank character as requested by sub,
+// returning the reply and the name of the affected character.
+func (h *Handler) changeAlt(guildID string, sub *discordgo.ApplicationCommandInteractionDataOption) (string, string, error) {
+	c := &database.BankCharacter{}
+	var userID string
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "name":
+			c.Name = strings.TrimSpace(opt.StringValue())
+		case "realm":
+			c.Realm = strings.TrimSpace(opt.StringValue())
+		case "faction":
+			c.Faction = opt.StringValue()
+		case "flavor":
+			c.Flavor = opt.StringValue()
+		case "user":
+			userID = opt.UserValue(nil).ID
+		}
+	}
+
+	if sub.Name == "add" {
+		err := h.gringotts.RegisterBankCharacter(context.Background(), guildID, c, userID)
+		if err != nil {
+			return "", c.Name, err
+		}
+
+		return fmt.Sprintf("registered <@%s> for %s", userID, c.Owner()), c.Name, nil
+	}
+
+	if userID != "" {
+		removed, err := h.gringotts.RemoveBankCharacterUser(context.Background(), guildID, c.Owner(), userID)
+		if err != nil {
+			return "", c.Name, err
+		}
+
+		if !removed {
+			return fmt.Sprintf("<@%s> is not registered for %s", userID, c.Owner()), c.Name, nil
+		}
+
+		return fmt.Sprintf("removed <@%s> from %s", userID, c.Owner()), c.Name, nil
+	}
+
+	removed, err := h.gringotts.RemoveBankCharacter(context.Background(), guildID, c.Owner())
+	if err != nil {
+		return "", c.Name, err
+	}
+
+	if !removed {
+		return fmt.Sprintf("%s is not registered", c.Owner()), c.Name, nil
+	}
+
+	return fmt.Sprintf("removed %s", c.Owner()), c.Name, nil
+}
@@ -0,0 +1,198 @@
+package interactions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+const (
+	// maxAuditOptionLength is the longest option value stored as is in the
+	// audit log, longer values such as inventory payloads are hashed.
+	maxAuditOptionLength = 256
+
+	// auditResultLimit is the most audit entries returned by /gbank audit.
+	auditResultLimit = 25
+
+	// maxContentLength is the most characters Discord accepts in a message.
+	maxContentLength = 2000
+)
+
+var auditSubCommand = &discordgo.ApplicationCommandOption{
+	Name:        "audit",
+	Description: "show the audit log of bank changes",
+	Type:        discordgo.ApplicationCommandOptionSubCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "only show commands run by this user",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "owner",
+			Description: "only show commands affecting this bank character, as Name or Name-Realm",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "since",
+			Description: "only show commands run on or after this date (YYYY-MM-DD)",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "until",
+			Description: "only show commands run on or before this date (YYYY-MM-DD)",
+		},
+	},
+}
+
+func (h *Handler) Audit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	filter := database.AuditFilter{Limit: auditResultLimit}
+	for _, opt := range i.ApplicationCommandData().Options[0].Options {
+		switch opt.Name {
+		case "user":
+			filter.UserID = opt.UserValue(nil).ID
+		case "owner":
+			filter.Owner = opt.StringValue()
+		case "since":
+			since, err := time.Parse(time.DateOnly, opt.StringValue())
+			if err != nil {
+				doFailedInteraction(s, i, fmt.Sprintf("invalid since date %s, expected YYYY-MM-DD", opt.StringValue()))
+				return
+			}
+			filter.Since = since
+		case "until":
+			until, err := time.Parse(time.DateOnly, opt.StringValue())
+			if err != nil {
+				doFailedInteraction(s, i, fmt.Sprintf("invalid until date %s, expected YYYY-MM-DD", opt.StringValue()))
+				return
+			}
+			filter.Until = until.AddDate(0, 0, 1)
+		}
+	}
+
+	entries, err := h.gringotts.FindAuditEntries(context.Background(), i.GuildID, filter)
+	if err != nil {
+		doFailedInteraction(s, i, fmt.Sprintf("unable to read audit log: %v", err))
+		return
+	}
+
+	lines := []string{}
+	for _, e := range entries {
+		line := fmt.Sprintf("<t:%d:f> %s (<@%s>) ran %s", e.CreatedAt.Unix(), e.Username, e.UserID, e.Command)
+		if e.Owner != "" {
+			line += fmt.Sprintf(" for %s", e.Owner)
+		}
+		line += fmt.Sprintf(": %s", e.Outcome)
+		if e.Message != "" {
+			line += fmt.Sprintf(" (%s)", e.Message)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "no matching audit entries")
+	}
+
+	respondEphemeral(s, i, truncateLines(lines, maxContentLength))
+}
+
+// recordAudit stores the outcome of a bank mutating command in the audit log.
+// owner is the bank character the command affected, if known. Button presses
+// are recorded with their custom ID as the command.
+func (h *Handler) recordAudit(i *discordgo.InteractionCreate, owner string, cmdErr error) {
+	if !h.features.AuditLog {
+		return
+	}
+
+	var command, options string
+	if i.Type == discordgo.InteractionMessageComponent {
+		command, options = i.MessageComponentData().CustomID, "{}"
+	} else {
+		data := i.ApplicationCommandData()
+		var opts []*discordgo.ApplicationCommandInteractionDataOption
+		command, opts = commandPath(data.Name, data.Options)
+		options = auditOptions(opts)
+	}
+
+	h.storeAudit(i, command, options, owner, cmdErr)
+}
+
+// storeAudit stores the outcome of command, run with the JSON encoded options
+// on behalf of the user behind i, in the audit log.
+func (h *Handler) storeAudit(i *discordgo.InteractionCreate, command, options, owner string, cmdErr error) {
+	if !h.features.AuditLog {
+		return
+	}
+
+	e := &database.AuditEntry{
+		GuildID: i.GuildID,
+		Command: command,
+		Options: options,
+		Owner:   owner,
+		Outcome: database.AuditOutcomeSuccess,
+	}
+
+	if u := interactionUser(i); u != nil {
+		e.UserID = u.ID
+		e.Username = u.Username
+	}
+
+	if cmdErr != nil {
+		e.Outcome = database.AuditOutcomeFailure
+		e.Message = cmdErr.Error()
+	}
+
+	err := h.gringotts.RecordAudit(context.Background(), e)
+	if err != nil {
+		slog.Error("unable to record audit entry", "command", command, "err", err)
+	}
+}
+
+// commandPath returns the full command name including any subcommand groups and
+// subcommands, along with the options passed to the innermost one.
+func commandPath(name string, opts []*discordgo.ApplicationCommandInteractionDataOption) (string, []*discordgo.ApplicationCommandInteractionDataOption) {
+	path := []string{name}
+	for len(opts) == 1 && (opts[0].Type == discordgo.ApplicationCommandOptionSubCommand || opts[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup) {
+		path = append(path, opts[0].Name)
+		opts = opts[0].Options
+	}
+
+	return strings.Join(path, " "), opts
+}
+
+// auditOptions encodes opts as JSON, replacing long values with their hash.
+func auditOptions(opts []*discordgo.ApplicationCommandInteractionDataOption) string {
+	values := map[string]any{}
+	for _, opt := range opts {
+		v := opt.Value
+		if str, ok := v.(string); ok && len(str) > maxAuditOptionLength {
+			v = fmt.Sprintf("sha256:%x (%d bytes)", sha256.Sum256([]byte(str)), len(str))
+		}
+		values[opt.Name] = v
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "{}"
+	}
+
+	return string(b)
+}
+
+// interactionUser returns the user who triggered i, whether it came from a
+// guild or a direct message.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+
+	return i.User
+}
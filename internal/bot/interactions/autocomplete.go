@@ -0,0 +1,72 @@
+package interactions
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxAutocompleteChoices is the most choices Discord accepts in an
+// autocomplete response.
+const maxAutocompleteChoices = 25
+
+// Autocomplete answers autocomplete requests for item name options with the
+// names of the best matching known items, and for browse subcategories with
+// the subcategories of the chosen category.
+func (h *Handler) Autocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opt := focusedOption(i.ApplicationCommandData().Options)
+
+	ok, err := h.authorized(i, requiredCapability(i))
+	if err != nil {
+		slog.Error("unable to check autocomplete permissions", "err", err)
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	switch {
+	case opt == nil || !ok || !h.features.Autocomplete:
+	case opt.Name == "subcategory":
+		choices = h.subcategoryChoices(i, opt.StringValue())
+	default:
+		items, err := h.gringotts.FindItem(context.Background(), i.GuildID, opt.StringValue(), maxAutocompleteChoices, 0)
+		if err != nil {
+			slog.Error("unable to find autocomplete choices", "err", err)
+		}
+
+		for _, item := range items {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  item.Name,
+				Value: item.Name,
+			})
+		}
+	}
+
+	err = s.InteractionRespond(
+		i.Interaction,
+		&discordgo.InteractionResponse{
+			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+			Data: &discordgo.InteractionResponseData{
+				Choices: choices,
+			},
+		},
+	)
+	if err != nil {
+		slog.Warn("unable to respond to autocomplete", "err", err)
+	}
+}
+
+// focusedOption returns the option the user is currently typing in, looking
+// through subcommands, or nil if there is none.
+func focusedOption(opts []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range opts {
+		if opt.Focused {
+			return opt
+		}
+
+		if f := focusedOption(opt.Options); f != nil {
+			return f
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+const (
+	browseComponentPrefix = "browse"
+	browseEmbedColor      = 0x2ecc71
+
+	// maxSubcategoryLength keeps the subcategory short enough to be carried
+	// in the custom ID of the paging buttons.
+	maxSubcategoryLength = 64
+)
+
+// categoryChoices are the item classes that can be browsed.
+var categoryChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "consumable", Value: database.ItemClassConsumable},
+	{Name: "container", Value: database.ItemClassContainer},
+	{Name: "weapon", Value: database.ItemClassWeapon},
+	{Name: "gem", Value: database.ItemClassGem},
+	{Name: "armor", Value: database.ItemClassArmor},
+	{Name: "reagent", Value: database.ItemClassReagent},
+	{Name: "projectile", Value: database.ItemClassProjectile},
+	{Name: "trade goods", Value: database.ItemClassTradeGoods},
+	{Name: "recipe", Value: database.ItemClassRecipe},
+	{Name: "quiver", Value: database.ItemClassQuiver},
+	{Name: "quest", Value: database.ItemClassQuest},
+	{Name: "key", Value: database.ItemClassKey},
+	{Name: "miscellaneous", Value: database.ItemClassMiscellaneous},
+}
+
+var browseSubCommand = &discordgo.ApplicationCommandOption{
+	Name:        "browse",
+	Description: "list the items in the bank by category",
+	Type:        discordgo.ApplicationCommandOptionSubCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "category",
+			Description: "category of the items",
+			Required:    true,
+			Choices:     categoryChoices,
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "subcategory",
+			Description:  "only list items of this subcategory, such as herb or potion",
+			Autocomplete: true,
+			MaxLength:    maxSubcategoryLength,
+		},
+	},
+}
+
+func (h *Handler) Browse(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var classID int
+	var subclass string
+	for _, opt := range i.ApplicationCommandData().Options[0].Options {
+		switch opt.Name {
+		case "category":
+			classID = int(opt.IntValue())
+		case "subcategory":
+			subclass = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	data, err := h.browsePage(i.GuildID, classID, subclass, 0)
+	if err != nil {
+		doFailedInteraction(s, i, fmt.Sprintf("unable to browse the bank: %v", err))
+		return
+	}
+
+	err = respond(s, i, data)
+	if err != nil {
+		doFailedInteraction(s, i, err.Error())
+		return
+	}
+}
+
+// browsePage renders the requested zero based page of the items of classID,
+// narrowed down to subclass unless it is empty.
+func (h *Handler) browsePage(guildID string, classID int, subclass string, page int) (*discordgo.InteractionResponseData, error) {
+	// fetch one extra item to know whether there is a next page
+	items, err := h.gringotts.BrowseCategory(context.Background(), guildID, classID, subclass, searchPageSize+1, page*searchPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	hasNext := len(items) > searchPageSize
+	if hasNext {
+		items = items[:searchPageSize]
+	}
+
+	category := categoryName(classID)
+	if subclass != "" {
+		category += " / " + subclass
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("%s in the bank", category),
+		Color: browseEmbedColor,
+	}
+
+	for _, i := range items {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  i.Name,
+			Value: h.itemFieldValue(guildID, i),
+		})
+	}
+
+	if len(items) > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("page %d", page+1)}
+	} else {
+		embed.Description = fmt.Sprintf("no %s found", category)
+	}
+
+	data := &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		// always set so paging to a page without buttons removes them
+		Components: []discordgo.MessageComponent{},
+	}
+
+	if page > 0 || hasNext {
+		data.Components = pageButtons(page, hasNext, func(page int) string {
+			return browseCustomID(page, classID, subclass)
+		})
+	}
+
+	return data, nil
+}
+
+// categoryName returns the name of the choice for classID.
+func categoryName(classID int) string {
+	for _, c := range categoryChoices {
+		if c.Value == classID {
+			return c.Name
+		}
+	}
+
+	return fmt.Sprintf("category %d", classID)
+}
+
+// subcategoryChoices returns the subcategories of the category chosen in i
+// that contain value.
+func (h *Handler) subcategoryChoices(i *discordgo.InteractionCreate, value string) []*discordgo.ApplicationCommandOptionChoice {
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+
+	var classID int64 = -1
+	for _, opt := range i.ApplicationCommandData().Options[0].Options {
+		if opt.Name == "category" && opt.Value != nil {
+			classID = opt.IntValue()
+		}
+	}
+
+	if classID < 0 {
+		return choices
+	}
+
+	subclasses, err := h.gringotts.ListSubclasses(context.Background(), int(classID))
+	if err != nil {
+		slog.Error("unable to find subcategory choices", "err", err)
+		return choices
+	}
+
+	for _, sc := range subclasses {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+
+		if strings.Contains(strings.ToLower(sc.Name), strings.ToLower(value)) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: sc.Name, Value: sc.Name})
+		}
+	}
+
+	return choices
+}
+
+// browseCustomID encodes a page of a category in a button custom ID.
+func browseCustomID(page, classID int, subclass string) string {
+	return fmt.Sprintf("%s:%d:%d:%s", browseComponentPrefix, page, classID, subclass)
+}
+
+// parseBrowseCustomID decodes a custom ID created by browseCustomID.
+func parseBrowseCustomID(customID string) (int, int, string, error) {
+	parts := strings.SplitN(customID, ":", 4)
+	if len(parts) != 4 || parts[0] != browseComponentPrefix {
+		return 0, 0, "", fmt.Errorf("invalid browse custom id %s", customID)
+	}
+
+	page, err := strconv.Atoi(parts[1])
+	if err != nil || page < 0 {
+		return 0, 0, "", fmt.Errorf("invalid browse page in custom id %s", customID)
+	}
+
+	classID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid browse category in custom id %s", customID)
+	}
+
+	return page, classID, parts[3], nil
+}
@@ -0,0 +1,157 @@
+package interactions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/itemlink"
+)
+
+const (
+	// maxEmbedFieldLength is the most characters Discord accepts in an embed
+	// field value.
+	maxEmbedFieldLength = 1024
+
+	// maxEmbedsLength is the most characters Discord accepts across the
+	// titles, descriptions, fields and footers of all embeds of a message.
+	maxEmbedsLength = 6000
+
+	diffEmbedColor = 0x2ecc71
+)
+
+// inventoryDiff is the changes between the previous and the newly uploaded
+// inventory of one character.
+type inventoryDiff struct {
+	charName string
+	// flavor is the game flavor items are linked for.
+	flavor string
+	diffs  []*database.ItemDiff
+	// first is set when there was no previous upload to compare against.
+	first bool
+}
+
+// inventoryDiffEmbeds renders an embed per uploaded inventory. Fields are
+// shortened evenly when the embeds would not fit in a single message.
+func inventoryDiffEmbeds(links *itemlink.Linker, uploads []*inventoryDiff) []*discordgo.MessageEmbed {
+	render := func(fieldLength int) []*discordgo.MessageEmbed {
+		var embeds []*discordgo.MessageEmbed
+		for _, u := range uploads {
+			embeds = append(embeds, inventoryDiffEmbed(links, u, fieldLength))
+		}
+		return embeds
+	}
+
+	embeds := render(maxEmbedFieldLength)
+	if embedsLength(embeds) <= maxEmbedsLength {
+		return embeds
+	}
+
+	// share what the titles and field names leave among the field values
+	var fields, values int
+	for _, e := range embeds {
+		for _, f := range e.Fields {
+			fields++
+			values += len(f.Value)
+		}
+	}
+
+	return render((maxEmbedsLength - (embedsLength(embeds) - values)) / fields)
+}
+
+// embedsLength returns the length of embeds as Discord counts it against
+// maxEmbedsLength. Bytes are counted, which is never less than characters.
+func embedsLength(embeds []*discordgo.MessageEmbed) int {
+	var n int
+	for _, e := range embeds {
+		n += len(e.Title) + len(e.Description)
+		for _, f := range e.Fields {
+			n += len(f.Name) + len(f.Value)
+		}
+		if e.Footer != nil {
+			n += len(e.Footer.Text)
+		}
+		if e.Author != nil {
+			n += len(e.Author.Name)
+		}
+	}
+
+	return n
+}
+
+// inventoryDiffEmbed renders u, shortening field values to fieldLength.
+func inventoryDiffEmbed(links *itemlink.Linker, u *inventoryDiff, fieldLength int) *discordgo.MessageEmbed {
+	var added, removed, changed []string
+	for _, d := range u.diffs {
+		name := d.Name
+		if name == "" {
+			name = d.ID
+		}
+		name = links.Markdown(name, d.ID, u.flavor)
+
+		switch {
+		case d.Before == 0:
+			added = append(added, fmt.Sprintf("%s x%d", name, d.After))
+		case d.After == 0:
+			removed = append(removed, fmt.Sprintf("%s x%d", name, d.Before))
+		default:
+			changed = append(changed, fmt.Sprintf("%s %d → %d (%+d)", name, d.Before, d.After, d.Delta()))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("loaded inventory data for %s", u.charName),
+		Color: diffEmbedColor,
+	}
+
+	switch {
+	case u.first:
+		embed.Description = "first upload, no previous inventory to compare against"
+	case len(u.diffs) == 0:
+		embed.Description = "no changes since the last upload"
+	}
+
+	for _, f := range []struct {
+		name  string
+		lines []string
+	}{
+		{name: "Added", lines: added},
+		{name: "Removed", lines: removed},
+		{name: "Changed", lines: changed},
+	} {
+		if len(f.lines) == 0 {
+			continue
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s (%d)", f.name, len(f.lines)),
+			Value: truncateLines(f.lines, fieldLength),
+		})
+	}
+
+	return embed
+}
+
+// truncateLines joins lines with newlines, dropping trailing lines and noting
+// how many were left out when the result would exceed max bytes.
+func truncateLines(lines []string, max int) string {
+	b := strings.Builder{}
+	for k, l := range lines {
+		// keep room to report the dropped lines unless this is the last one
+		reserve := 0
+		if k < len(lines)-1 {
+			reserve = len(fmt.Sprintf("\n… and %d more", len(lines)-k-1))
+		}
+
+		if b.Len()+len(l)+1+reserve > max {
+			b.WriteString(fmt.Sprintf("… and %d more", len(lines)-k))
+			return b.String()
+		}
+
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,47 @@
+package interactions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/itemlink"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryDiffEmbeds(t *testing.T) {
+	links := itemlink.New(itemlink.SiteWowhead, database.FlavorClassic, nil)
+
+	var diffs []*database.ItemDiff
+	for k := 0; k < 200; k++ {
+		id := fmt.Sprint(1000 + k)
+		diffs = append(diffs,
+			&database.ItemDiff{ID: id, Name: "Added Item " + id, After: 1},
+			&database.ItemDiff{ID: id + "0", Name: "Removed Item " + id, Before: 1},
+			&database.ItemDiff{ID: id + "1", Name: "Changed Item " + id, Before: 1, After: 2},
+		)
+	}
+
+	// one busy character fits without shortening beyond the field limit
+	embeds := inventoryDiffEmbeds(links, []*inventoryDiff{{charName: "Gbank", diffs: diffs}})
+	require.Len(t, embeds, 1)
+	require.Len(t, embeds[0].Fields, 3)
+	require.LessOrEqual(t, embedsLength(embeds), maxEmbedsLength)
+
+	var uploads []*inventoryDiff
+	for k := 0; k < maxInventoriesPerUpload; k++ {
+		uploads = append(uploads, &inventoryDiff{charName: fmt.Sprintf("Gbank%d", k), diffs: diffs})
+	}
+
+	embeds = inventoryDiffEmbeds(links, uploads)
+	require.Len(t, embeds, maxInventoriesPerUpload)
+	require.LessOrEqual(t, embedsLength(embeds), maxEmbedsLength)
+	for _, e := range embeds {
+		require.Len(t, e.Fields, 3)
+		require.Contains(t, e.Fields[0].Value, "more")
+	}
+
+	embeds = inventoryDiffEmbeds(links, []*inventoryDiff{{charName: "Gbank", first: true}})
+	require.Empty(t, embeds[0].Fields)
+	require.Contains(t, embeds[0].Description, "first upload")
+}
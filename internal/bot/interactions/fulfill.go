@@ -0,0 +1,121 @@
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+const fulfillEmbedColor = 0x95a5a6
+
+// WithRequests sets the channel of each guild that uploads fulfilling approved
+// requests are reported in, and whether those requests are marked fulfilled
+// rather than only proposed. Guilds without a channel are not checked.
+func WithRequests(channels map[string]string, autoFulfill bool) Option {
+	return func(h *Handler) {
+		h.requestChannels = channels
+		h.autoFulfill = autoFulfill
+	}
+}
+
+// itemDrops adds how many of each item left the bank according to diffs to
+// drops. Items gained count against those lost, so moving items between bank
+// characters in one upload is not mistaken for handing them out.
+func itemDrops(drops map[string]int, diffs []*database.ItemDiff) {
+	for _, d := range diffs {
+		drops[d.ID] -= d.Delta()
+	}
+}
+
+// fulfillRequests matches the items that left the bank in the upload i against
+// the approved requests of its guild. Matching requests are marked fulfilled
+// and audited if the handler is set to, and reported in the request channel of
+// the guild either way. Problems are logged as the upload itself succeeded.
+func (h *Handler) fulfillRequests(s *discordgo.Session, i *discordgo.InteractionCreate, owner string, drops map[string]int) {
+	guildID := i.GuildID
+	channelID := h.requestChannels[guildID]
+	if channelID == "" || len(drops) == 0 {
+		return
+	}
+
+	requests, err := h.gringotts.FulfilledRequests(context.Background(), guildID, drops)
+	if err != nil {
+		slog.Error("unable to match requests to upload", "owner", owner, "err", err)
+		return
+	}
+
+	title := fmt.Sprintf("requests the upload of %s may have fulfilled", owner)
+	if h.autoFulfill {
+		title = fmt.Sprintf("requests fulfilled by the upload of %s", owner)
+	}
+
+	var userID string
+	if u := interactionUser(i); u != nil {
+		userID = u.ID
+	}
+
+	var lines []string
+	for _, req := range requests {
+		if h.autoFulfill {
+			fulfilled, err := h.gringotts.UpdateRequestStatus(context.Background(), guildID, req.ID, database.RequestFulfilled, userID)
+			h.storeAudit(i, requestCustomID(database.RequestFulfilled, req.ID), `{"automatic":true}`, owner, err)
+			if err != nil {
+				slog.Error("unable to fulfill request", "request", req.ID, "err", err)
+				continue
+			}
+
+			req = fulfilled
+			h.updateRequestMessage(s, guildID, req)
+		}
+
+		line := fmt.Sprintf("#%d %s for <@%s>", req.ID, h.requestedItems(guildID, req), req.RequesterID)
+		if req.MessageID != "" {
+			line += fmt.Sprintf(" ([request](https://discord.com/channels/%s/%s/%s))", guildID, req.ChannelID, req.MessageID)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Color:       fulfillEmbedColor,
+		Description: truncateLines(lines, maxEmbedFieldLength),
+	}
+
+	if !h.autoFulfill {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "mark them fulfilled on the request messages"}
+	}
+
+	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error("unable to post fulfilled requests", "channel", channelID, "err", err)
+	}
+}
+
+// updateRequestMessage re-renders the message req was posted in, if known.
+func (h *Handler) updateRequestMessage(s *discordgo.Session, guildID string, req *database.ItemRequest) {
+	if req.ChannelID == "" || req.MessageID == "" {
+		return
+	}
+
+	data := h.requestMessage(guildID, req)
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              req.MessageID,
+		Channel:         req.ChannelID,
+		Embeds:          data.Embeds,
+		Components:      data.Components,
+		AllowedMentions: data.AllowedMentions,
+	})
+	if err != nil {
+		slog.Warn("unable to update request message", "request", req.ID, "err", err)
+	}
+}
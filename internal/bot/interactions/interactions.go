@@ -3,11 +3,15 @@ package interactions
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/inventory"
+	"github.com/jbweber/gringotts-bot/internal/itemlink"
 )
 
 var Commands = []*discordgo.ApplicationCommand{
@@ -21,11 +25,14 @@ var Commands = []*discordgo.ApplicationCommand{
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Options: []*discordgo.ApplicationCommandOption{
 					{
-						Type:        discordgo.ApplicationCommandOptionString,
-						Name:        "name",
-						Description: "name of the item to search for",
-						Required:    true,
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "name of the item to search for",
+						Required:     true,
+						Autocomplete: true,
+						MaxLength:    maxSearchLength,
 					},
+					minQualityOption,
 				},
 			},
 			{
@@ -34,36 +41,67 @@ var Commands = []*discordgo.ApplicationCommand{
 				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Options: []*discordgo.ApplicationCommandOption{
 					{
-						Type:        discordgo.ApplicationCommandOptionString,
-						Name:        "name",
-						Description: "name of the item to search for",
-						Required:    true,
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "name",
+						Description:  "name of the item to search for",
+						Required:     true,
+						Autocomplete: true,
+						MaxLength:    maxSearchLength,
 					},
 					{
 						Type:        discordgo.ApplicationCommandOptionString,
 						Name:        "location",
-						Description: "name of the item to search for",
-						Required:    true,
+						Description: "where to look for the item",
+						Required:    false,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "bags", Value: database.LocationBags},
+							{Name: "bank", Value: database.LocationBank},
+							{Name: "reagent bank", Value: database.LocationReagentBank},
+							{Name: "mail", Value: database.LocationMail},
+							{Name: "equipped", Value: database.LocationEquipped},
+						},
 					},
 				},
 			},
+			browseSubCommand,
+			requestSubCommand,
+			requestsSubCommand,
+			auditSubCommand,
+			permsSubCommandGroup,
+			altSubCommandGroup,
 		},
+		DMPermission: &dmPermission,
 	},
 	{
 		Name:        "find-item",
 		Description: "find an item in the bank",
 		Options: []*discordgo.ApplicationCommandOption{
 			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "item-name",
-				Description: "name of the item to search for",
-				Required:    true,
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "item-name",
+				Description:  "name of the item to search for",
+				Required:     true,
+				Autocomplete: true,
+				MaxLength:    maxSearchLength,
 			},
+			minQualityOption,
 		},
+		DMPermission: &dmPermission,
 	},
 	loadInventoryCommand,
 }
 
+// dmPermission disables commands in direct messages, permissions are granted
+// to guild roles so every command needs a guild member.
+var dmPermission = false
+
+// managePermission hides commands that need more than read access from members
+// who can not manage the server until the server opens them to other roles in
+// its integration settings. The capabilities granted with /gbank perms are
+// still checked on every use. gbank mixes read and admin subcommands and
+// Discord only applies this to whole commands, so it is left visible.
+var managePermission int64 = discordgo.PermissionManageServer
+
 //var Handler = func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 //	data := i.ApplicationCommandData()
 //	switch data.Name {
@@ -86,15 +124,143 @@ var Commands = []*discordgo.ApplicationCommand{
 //	}
 //}
 
+// CommandsFor returns Commands adjusted for the enabled features.
+func CommandsFor(f Features) []*discordgo.ApplicationCommand {
+	if f.Autocomplete {
+		return Commands
+	}
+
+	commands := make([]*discordgo.ApplicationCommand, 0, len(Commands))
+	for _, c := range Commands {
+		copied := *c
+		copied.Options = withoutAutocomplete(c.Options)
+		commands = append(commands, &copied)
+	}
+
+	return commands
+}
+
+// withoutAutocomplete copies opts and their suboptions with autocomplete
+// turned off.
+func withoutAutocomplete(opts []*discordgo.ApplicationCommandOption) []*discordgo.ApplicationCommandOption {
+	copied := make([]*discordgo.ApplicationCommandOption, 0, len(opts))
+	for _, opt := range opts {
+		o := *opt
+		o.Autocomplete = false
+		o.Options = withoutAutocomplete(opt.Options)
+		copied = append(copied, &o)
+	}
+
+	return copied
+}
+
+// Features toggle optional behavior of the handler.
+type Features struct {
+	// Autocomplete suggests item names while typing search options.
+	Autocomplete bool
+	// SearchSuggestions answers searches that match nothing with similar
+	// item names.
+	SearchSuggestions bool
+	// AuditLog records commands in the audit log.
+	AuditLog bool
+}
+
+// DefaultFeatures has every feature enabled.
+var DefaultFeatures = Features{
+	Autocomplete:      true,
+	SearchSuggestions: true,
+	AuditLog:          true,
+}
+
 type Handler struct {
-	gringotts *database.Gringotts
+	gringotts  *database.Gringotts
+	httpClient *http.Client
+	// defaultCapabilities are held by every guild member regardless of role.
+	defaultCapabilities []string
+	features            Features
+	links               *itemlink.Linker
+	// requestChannels are the channels of each guild that uploads fulfilling
+	// approved requests are reported in.
+	requestChannels map[string]string
+	autoFulfill     bool
+}
+
+// Option configures a Handler.
+type Option func(h *Handler)
+
+// WithDefaultCapabilities sets the capabilities held by every guild member,
+// read by default.
+func WithDefaultCapabilities(capabilities []string) Option {
+	return func(h *Handler) {
+		h.defaultCapabilities = capabilities
+	}
+}
+
+// WithFeatures sets the enabled features, DefaultFeatures by default.
+func WithFeatures(f Features) Option {
+	return func(h *Handler) {
+		h.features = f
+	}
 }
 
-func NewHandler(g *database.Gringotts) *Handler {
-	return &Handler{gringotts: g}
+// WithItemLinks sets how items are linked, to classic wowhead pages by
+// default.
+func WithItemLinks(l *itemlink.Linker) Option {
+	return func(h *Handler) {
+		h.links = l
+	}
+}
+
+func NewHandler(g *database.Gringotts, opts ...Option) *Handler {
+	h := &Handler{
+		gringotts:           g,
+		httpClient:          &http.Client{Timeout: 30 * time.Second},
+		defaultCapabilities: []string{database.CapabilityRead},
+		features:            DefaultFeatures,
+		links:               itemlink.New(itemlink.SiteWowhead, database.FlavorClassic, nil),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 func (h *Handler) Handle(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionMessageComponent:
+		break
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		h.Autocomplete(s, i)
+		return
+	default:
+		return
+	}
+
+	// permissions are checked before deferring so denials are only shown to
+	// the caller, not posted in the channel or over a shared message
+	capability := requiredCapability(i)
+	ok, err := h.authorized(i, capability)
+	if err != nil {
+		respondPrivately(s, i, fmt.Sprintf("unable to check permissions: %v", err))
+		return
+	}
+
+	if !ok {
+		respondPrivately(s, i, fmt.Sprintf("you need the %s capability to run this command", capability))
+		return
+	}
+
+	// acknowledge slow commands before doing anything else, every reply after
+	// this goes through respond which edits the deferred response
+	deferResponse(s, i)
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		h.HandleComponent(s, i)
+		return
+	}
+
 	data := i.ApplicationCommandData()
 	switch data.Name {
 	case "gbank":
@@ -103,6 +269,29 @@ func (h *Handler) Handle(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		case "search":
 			h.FindItem2(s, i)
 			break
+		case "sniff":
+			h.SniffItem(s, i)
+			break
+		case "browse":
+			h.Browse(s, i)
+			break
+		case "request":
+			h.RequestItem(s, i)
+			break
+		case "requests":
+			h.Requests(s, i)
+			break
+		case "audit":
+			h.Audit(s, i)
+			break
+		case "perms":
+			h.Permissions(s, i)
+			break
+		case "alt":
+			h.Alts(s, i)
+			break
+		default:
+			doFailedInteraction(s, i, fmt.Sprintf("unknown subcommand %s", options[0].Name))
 		}
 	case "find-item":
 		h.FindItem(s, i)
@@ -122,31 +311,15 @@ func (h *Handler) FindItem(s *discordgo.Session, i *discordgo.InteractionCreate)
 	//
 	//}
 
-	itemName := opts[0]
-
-	itemNameStr := itemName.Value.(string)
+	itemNameStr, minQuality := searchOptions(opts)
 
-	items, err := h.gringotts.FindItem(context.Background(), itemNameStr)
+	data, err := h.searchPage(i.GuildID, itemNameStr, minQuality, 0)
 	if err != nil {
 		doFailedInteraction(s, i, fmt.Sprintf("unable to find item: %v", err))
 		return
 	}
 
-	content := strings.Builder{}
-	for _, i := range items {
-		content.WriteString(fmt.Sprintf("found %d of item %s with id %s\n", i.Count, getWowheadURL(i.Name, i.ID), i.ID))
-	}
-
-	err = s.InteractionRespond(
-		i.Interaction,
-		&discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: content.String(),
-				Flags:   discordgo.MessageFlagsSuppressEmbeds,
-			},
-		},
-	)
+	err = respond(s, i, data)
 	if err != nil {
 		doFailedInteraction(s, i, err.Error())
 		return
@@ -160,95 +333,228 @@ func (h *Handler) FindItem2(s *discordgo.Session, i *discordgo.InteractionCreate
 	//
 	//}
 
-	itemName := opts[0].Options[0]
-
-	itemNameStr := itemName.Value.(string)
+	itemNameStr, minQuality := searchOptions(opts[0].Options)
 
-	items, err := h.gringotts.FindItem(context.Background(), itemNameStr)
+	data, err := h.searchPage(i.GuildID, itemNameStr, minQuality, 0)
 	if err != nil {
 		doFailedInteraction(s, i, fmt.Sprintf("unable to find item: %v", err))
 		return
 	}
 
-	content := strings.Builder{}
-	for _, i := range items {
-		content.WriteString(fmt.Sprintf("found %d of item %s with id %s\n", i.Count, getWowheadURL(i.Name, i.ID), i.ID))
-	}
-
-	err = s.InteractionRespond(
-		i.Interaction,
-		&discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: content.String(),
-				Flags:   discordgo.MessageFlagsSuppressEmbeds,
-			},
-		},
-	)
+	err = respond(s, i, data)
 	if err != nil {
 		doFailedInteraction(s, i, err.Error())
 		return
 	}
 }
 
-func getWowheadURL(name, id string) string {
-	return fmt.Sprintf("[%s](https://www.wowhead.com/classic/item=%s)", name, id)
-}
+func (h *Handler) SniffItem(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	opts := i.Interaction.ApplicationCommandData().Options[0].Options
 
-func (h *Handler) LoadInventory(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	opts := i.Interaction.ApplicationCommandData().Options
+	var itemNameStr, locationStr string
+	for _, opt := range opts {
+		switch opt.Name {
+		case "name":
+			itemNameStr = opt.StringValue()
+		case "location":
+			locationStr = opt.StringValue()
+		}
+	}
 
-	//if len(opts) > 1 {
-	//
-	//}
+	locations, err := h.gringotts.FindItemLocations(context.Background(), i.GuildID, itemNameStr, locationStr)
+	if err != nil {
+		doFailedInteraction(s, i, fmt.Sprintf("unable to find item: %v", err))
+		return
+	}
 
-	charData := opts[0]
+	var lines []string
+	if len(locations) == 0 {
+		lines = append(lines, fmt.Sprintf("no items matching %s found", itemNameStr))
+	}
+	for _, l := range locations {
+		link := h.links.Markdown(l.Name, l.ID, h.links.Flavor(i.GuildID, l.Owner))
+		lines = append(lines, fmt.Sprintf("%s has %d of item %s in %s", l.Owner, l.Count, link, l.Location))
+	}
 
-	charDataStr := charData.Value.(string)
-	r, err := ParseInventoryData(charDataStr)
+	err = respond(s, i, &discordgo.InteractionResponseData{
+		Content: truncateLines(lines, maxContentLength),
+		Flags:   discordgo.MessageFlagsSuppressEmbeds,
+	})
 	if err != nil {
 		doFailedInteraction(s, i, err.Error())
 		return
 	}
+}
 
-	err = h.gringotts.UpdateItemCounts(context.Background(), r.CharName, r.ItemCounts)
+func (h *Handler) LoadInventory(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	drops := map[string]int{}
+	embeds, owner, err := h.loadInventory(i, drops)
+	h.recordAudit(i, owner, err)
 	if err != nil {
 		doFailedInteraction(s, i, err.Error())
 		return
 	}
 
-	err = h.gringotts.UpdateItems(context.Background(), r.ItemNames)
+	err = respond(s, i, &discordgo.InteractionResponseData{
+		Embeds: embeds,
+	})
 	if err != nil {
 		doFailedInteraction(s, i, err.Error())
-		return
 	}
 
-	err = s.InteractionRespond(
-		i.Interaction,
-		&discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("loaded inventory data for %s", r.CharName),
-			},
-		},
-	)
+	// the upload is stored whether or not the reply made it, so the requests
+	// it fulfilled are matched either way
+	h.fulfillRequests(s, i, owner, drops)
+}
+
+// loadInventory stores the uploaded inventories and returns an embed per
+// character describing what changed, sized to fit one message, along with the
+// bank characters they were uploaded for. How many of each item left the bank is added to drops.
+func (h *Handler) loadInventory(i *discordgo.InteractionCreate, drops map[string]int) ([]*discordgo.MessageEmbed, string, error) {
+	payload, err := h.inventoryPayload(context.Background(), i)
 	if err != nil {
-		doFailedInteraction(s, i, err.Error())
-		return
+		return nil, "", err
+	}
+
+	var opts inventory.Options
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "character" {
+			opts.CharName = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	results, err := inventory.Import([]byte(payload), opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var owners []string
+	for _, r := range results {
+		owners = append(owners, r.Owner().String())
+	}
+	owner := strings.Join(owners, ", ")
+
+	if len(results) > maxInventoriesPerUpload {
+		return nil, owner, fmt.Errorf("found %d characters, at most %d can be loaded at once", len(results), maxInventoriesPerUpload)
+	}
+
+	var userID string
+	if u := interactionUser(i); u != nil {
+		userID = u.ID
 	}
+
+	// every character is checked before anything is stored so an upload is
+	// never partially applied because of a registration problem
+	for _, r := range results {
+		registered, err := h.gringotts.IsBankCharacterUser(context.Background(), i.GuildID, r.Owner(), userID)
+		if err != nil {
+			return nil, owner, err
+		}
+
+		if !registered {
+			return nil, owner, fmt.Errorf("you are not registered to upload inventory for %s, ask an officer to run /gbank alt add", r.Owner())
+		}
+	}
+
+	var uploads []*inventoryDiff
+	for _, r := range results {
+		u, err := h.storeInventory(i.GuildID, r)
+		if err != nil {
+			return nil, owner, err
+		}
+
+		uploads = append(uploads, u)
+		itemDrops(drops, u.diffs)
+	}
+
+	return inventoryDiffEmbeds(h.links, uploads), owner, nil
+}
+
+// storeInventory stores one character's inventory as a new snapshot and
+// returns what changed since the previous one.
+func (h *Handler) storeInventory(guildID string, r *inventory.InventoryData) (*inventoryDiff, error) {
+	err := h.gringotts.UpdateItemLocations(context.Background(), guildID, r.Owner(), r.Locations())
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.gringotts.UpdateItems(context.Background(), r.ItemNames)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.gringotts.UpdateItemQualities(context.Background(), r.ItemQualities)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := h.gringotts.ListSnapshots(context.Background(), guildID, r.Owner(), 2)
+	if err != nil {
+		return nil, err
+	}
+
+	var previousID int64
+	if len(snapshots) > 1 {
+		previousID = snapshots[1].ID
+	}
+
+	diffs, err := h.gringotts.DiffSnapshots(context.Background(), guildID, previousID, snapshots[0].ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inventoryDiff{
+		charName: r.Owner().String(),
+		flavor:   h.links.Flavor(guildID, r.Owner()),
+		diffs:    diffs,
+		first:    previousID == 0,
+	}, nil
 }
 
 func doFailedInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
+	if i.Type == discordgo.InteractionMessageComponent {
+		// reply privately instead of replacing the message the component is
+		// attached to, such as search results or a posted request
+		if _, deferred := isDeferred(i); deferred {
+			_, err := s.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+				Content: message,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			})
+			if err != nil {
+				slog.Error("unable to report failure", "failure", message, "err", err)
+			}
+
+			return
+		}
+
+		respondPrivately(s, i, message)
+		return
+	}
+
+	err := respond(s, i, &discordgo.InteractionResponseData{
+		Content:    message,
+		Embeds:     []*discordgo.MessageEmbed{},
+		Components: []discordgo.MessageComponent{},
+	})
+	if err != nil {
+		slog.Error("unable to report failure", "failure", message, "err", err)
+	}
+}
+
+// respondPrivately answers i, before it is acknowledged, with a new message
+// only the caller can see.
+func respondPrivately(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
 	err := s.InteractionRespond(
 		i.Interaction,
 		&discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
 				Content: message,
+				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		},
 	)
 	if err != nil {
-		log.Printf("error occurred, %v", err)
+		slog.Error("unable to respond privately", "err", err)
 	}
 }
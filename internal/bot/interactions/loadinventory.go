@@ -1,59 +1,112 @@
 package interactions
 
 import (
-	"bytes"
-	"compress/zlib"
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-type InventoryData struct {
-	CharName   string            `json:"charName"`
-	ItemCounts map[string]int    `json:"itemCounts"`
-	ItemNames  map[string]string `json:"itemNames"`
+const (
+	// maxInventoryFileSize is the largest inventory attachment that will be
+	// downloaded.
+	maxInventoryFileSize = 1 << 20
+
+	// maxInventoriesPerUpload is the number of characters one upload may
+	// carry, each gets an embed and a message holds at most 10.
+	maxInventoriesPerUpload = 10
+)
+
+var loadInventoryCommand = &discordgo.ApplicationCommand{
+	Name:        "load-inventory",
+	Description: "load-inventory",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "inventory-data",
+			Description: "inventory data from GringottsExporter or another supported addon export",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionAttachment,
+			Name:        "inventory-file",
+			Description: "file containing inventory data, for inventories too large to paste",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "character",
+			Description: "character the inventory belongs to, for formats that do not record it",
+			Required:    false,
+		},
+	},
+	DMPermission:             &dmPermission,
+	DefaultMemberPermissions: &managePermission,
 }
 
-func ParseInventoryData(input string) (*InventoryData, error) {
-	b, err := base64.StdEncoding.DecodeString(input)
-	if err != nil {
-		return nil, err
+// inventoryPayload returns the encoded inventory data passed to load-inventory,
+// either pasted as a string or downloaded from an attached file.
+func (h *Handler) inventoryPayload(ctx context.Context, i *discordgo.InteractionCreate) (string, error) {
+	data := i.ApplicationCommandData()
+	for _, opt := range data.Options {
+		switch opt.Name {
+		case "inventory-file":
+			attachmentID, _ := opt.Value.(string)
+			if data.Resolved == nil || data.Resolved.Attachments[attachmentID] == nil {
+				return "", fmt.Errorf("unable to find attachment %s", attachmentID)
+			}
+
+			b, err := h.downloadAttachment(ctx, data.Resolved.Attachments[attachmentID])
+			if err != nil {
+				return "", err
+			}
+
+			return strings.TrimSpace(string(b)), nil
+		case "inventory-data":
+			return strings.TrimSpace(opt.StringValue()), nil
+		}
 	}
 
-	inBuf := bytes.NewBuffer(b)
+	return "", errors.New("either inventory-data or inventory-file is required")
+}
+
+// downloadAttachment fetches the contents of an attachment, refusing anything
+// larger than maxInventoryFileSize.
+func (h *Handler) downloadAttachment(ctx context.Context, a *discordgo.MessageAttachment) ([]byte, error) {
+	if a.Size > maxInventoryFileSize {
+		return nil, fmt.Errorf("attachment %s is %d bytes, the limit is %d", a.Filename, a.Size, maxInventoryFileSize)
+	}
 
-	r, err := zlib.NewReader(inBuf)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = r.Close() }()
 
-	outBuf := bytes.NewBuffer(nil)
-	_, err = io.Copy(outBuf, r)
+	resp, err := h.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
-	var result InventoryData
-	err = json.Unmarshal(outBuf.Bytes(), &result)
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to download attachment %s: %s", a.Filename, resp.Status)
+	}
+
+	// the reported size is not trusted, read one byte past the limit to
+	// detect larger bodies
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxInventoryFileSize+1))
 	if err != nil {
 		return nil, err
 	}
 
-	return &result, nil
-}
+	if len(b) > maxInventoryFileSize {
+		return nil, fmt.Errorf("attachment %s is larger than the limit of %d bytes", a.Filename, maxInventoryFileSize)
+	}
 
-var loadInventoryCommand = &discordgo.ApplicationCommand{
-	Name:        "load-inventory",
-	Description: "load-inventory",
-	Options: []*discordgo.ApplicationCommandOption{
-		{
-			Type:        discordgo.ApplicationCommandOptionString,
-			Name:        "inventory-data",
-			Description: "encoded inventory data from GringottsExporter addon",
-			Required:    true,
-		},
-	},
+	return b, nil
 }
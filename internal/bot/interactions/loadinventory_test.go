@@ -1,18 +0,0 @@
-package interactions_test
-
-import (
-	"fmt"
-	"testing"
-
-	"github.com/jbweber/gringotts-bot/internal/bot/interactions"
-	"github.com/stretchr/testify/require"
-)
-
-var inventoryData = "eJxdVcuO47YS/RWiNtl4AL0leze2+zGIPWPYyTRwd7RES4woskFSdhqD/vcURYk3yKabKJNVp+qcOvoFdUf1dzow2MCR6pZpxr4cqew172EF3LJhp0ZpDWx+QZxmRQabNF5BllYVbPBQ5GkEm/UK4ijKY9gkeEqqKJ1+jKs8TmBTrSBJ8qTE2BRM0xw2mbuYFvNjzFz4J3kW4cUkXcE6Wec+ti6j+ZRFWRFi0XSqqgx/THJ8EBUOgSuX5Vg3xxjWquZiVYTgY0xcRmU5oYrTMi99zCHAi6mLZkkRhSKp72m9LvF17jspplhaJVgujhyEFN/myXRa+zaTqCrSkCVboDr0OT4pcx9ydV1vZeEaTnyNtIpdFgzFRTV3XiXr+UVSxfF8iuZTGVXxki5xVd0Q0nSZH7ZezVCq2GdJoiwuAgI3hMx3mft+MTjlKT69CpxGJhHMfcFO0A9yqammV1j0AHtuajVc1XUU1CpNzvQDFo3Ad952ttb0IZg2EBQDZ9aQI23Zg9E7I1vaQtAQHPidy5Y8GcNkzSBICg5U36kgX2veQJAX7JRmRN3Ik2ADc6oNcoOT5sZyiQUErXuy53RQ0r2dW4W9ZnS4KS4gDAL+NwpOJTmOjelH6TZi1ifsRo2wz2Pb4r9X3jhsXrDYT83f2YZ8k3du+JULbj/ISVmuJIRxw7ea1fQdFoXC7x1tWqp/M+St46ZnGgJdcBplbaeCP5VqlCJ7JTzMaTHg0o9CmHcsZJkmO8VdIS9KuIyy1dRMo/DbA+dRsloo283D9kIJuA98QMYbh38UkiHB/2nB7xm8qKvgkpxV3TNLnkfmIPndgldGtXVMPHM90eY3DflEDciJ0un6vHbgSTkoOxpY5AxbZARBYpY37qny+whv3HZMX6nul2bnVYBnFICx5FWpdwi7CluFvD9r2gZReCfDFkTDJLlQaegAy7rBHxrn+6VDVucJ+bVGlIjjwZjDMi0wXDqlrXko3TiYP5lsGZ2F6rceMHdzG/XH/5FOpgdfdU0lzhkraAh+Bq+076nm4ba3Bzg6E6Y47QsXd1wfXBdYDAOeBP+bawdgz1DUBjcTJwyLj+C4JOrCXLEaLCYEL1oN5ipQTxDc5V+ZXtzsUUxv01/PhE86+w+cHZVMjYb80FcIFgnLspDD6Kn3dorDw1oPdcd5bzWtZw/wfgUXixSZmgpGfvBFGA7mSdB2ZA7nAMHJYPYDB/RP2ThssPjxxKlFWtz4ZpeD3aQKvL1lda+c/jaLMv2nAi4PfrPYorGCQfhawEk9mL6NghzVXwqWjw28dArzHUfTaQfs8/Of2zv2BQ=="
-
-func TestParseInventoryData(t *testing.T) {
-	r, err := interactions.ParseInventoryData(inventoryData)
-	require.NoError(t, err)
-
-	fmt.Println(r.CharName)
-}
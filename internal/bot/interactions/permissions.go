@@ -0,0 +1,242 @@
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// capabilityLevels orders capabilities so that holding one implies holding
+// every capability with a lower level.
+var capabilityLevels = map[string]int{
+	database.CapabilityRead:   1,
+	database.CapabilityUpload: 2,
+	database.CapabilityAdmin:  3,
+}
+
+// commandCapabilities is the capability needed to run each command. Commands
+// not listed require admin.
+var commandCapabilities = map[string]string{
+	"find-item":          database.CapabilityRead,
+	"gbank search":       database.CapabilityRead,
+	"gbank sniff":        database.CapabilityRead,
+	"gbank browse":       database.CapabilityRead,
+	"gbank request":      database.CapabilityRead,
+	"gbank requests":     database.CapabilityRead,
+	"load-inventory":     database.CapabilityUpload,
+	"gbank audit":        database.CapabilityAdmin,
+	"gbank perms grant":  database.CapabilityAdmin,
+	"gbank perms revoke": database.CapabilityAdmin,
+	"gbank perms list":   database.CapabilityAdmin,
+	"gbank alt add":      database.CapabilityAdmin,
+	"gbank alt remove":   database.CapabilityAdmin,
+	"gbank alt list":     database.CapabilityRead,
+}
+
+// componentCapabilities is the capability needed to use each kind of message
+// component, keyed by custom ID prefix. Components not listed require admin.
+var componentCapabilities = map[string]string{
+	searchComponentPrefix: database.CapabilityRead,
+	browseComponentPrefix: database.CapabilityRead,
+	// requesters may cancel their own requests, which ChangeRequest checks
+	cancelRequestComponentPrefix: database.CapabilityRead,
+	requestComponentPrefix:       database.CapabilityAdmin,
+}
+
+var capabilityChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: database.CapabilityRead, Value: database.CapabilityRead},
+	{Name: database.CapabilityUpload, Value: database.CapabilityUpload},
+	{Name: database.CapabilityAdmin, Value: database.CapabilityAdmin},
+}
+
+var permsSubCommandGroup = &discordgo.ApplicationCommandOption{
+	Name:        "perms",
+	Description: "manage which roles can use the bank",
+	Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        "grant",
+			Description: "grant a capability to a role",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "role to grant the capability to",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "capability",
+					Description: "capability to grant",
+					Required:    true,
+					Choices:     capabilityChoices,
+				},
+			},
+		},
+		{
+			Name:        "revoke",
+			Description: "revoke a capability from a role",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "role to revoke the capability from",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "capability",
+					Description: "capability to revoke",
+					Required:    true,
+					Choices:     capabilityChoices,
+				},
+			},
+		},
+		{
+			Name:        "list",
+			Description: "list the capabilities granted to roles",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+	},
+}
+
+// authorized reports whether the member who triggered i holds capability.
+// Members who can manage the server are always authorized, everyone else gets
+// the handler's default capabilities plus those granted to their roles. The
+// guild ID is checked as a role so grants to @everyone apply.
+func (h *Handler) authorized(i *discordgo.InteractionCreate, capability string) (bool, error) {
+	if i.Member == nil {
+		return false, nil
+	}
+
+	if i.Member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageServer) != 0 {
+		return true, nil
+	}
+
+	roles := append([]string{i.GuildID}, i.Member.Roles...)
+	held, err := h.gringotts.GetCapabilities(context.Background(), i.GuildID, roles)
+	if err != nil {
+		return false, err
+	}
+
+	// the defaults are shared by every interaction, so they are copied rather
+	// than appended to
+	capabilities := make([]string, 0, len(h.defaultCapabilities)+len(held))
+	capabilities = append(capabilities, h.defaultCapabilities...)
+	capabilities = append(capabilities, held...)
+
+	for _, c := range capabilities {
+		if capabilityLevels[c] >= capabilityLevels[capability] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// requiredCapability returns the capability needed to run the command or use
+// the component in i.
+func requiredCapability(i *discordgo.InteractionCreate) string {
+	if i.Type == discordgo.InteractionMessageComponent {
+		prefix, _, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+		if c, ok := componentCapabilities[prefix]; ok {
+			return c
+		}
+
+		return database.CapabilityAdmin
+	}
+
+	data := i.ApplicationCommandData()
+	command, _ := commandPath(data.Name, data.Options)
+
+	if c, ok := commandCapabilities[command]; ok {
+		return c
+	}
+
+	return database.CapabilityAdmin
+}
+
+func (h *Handler) Permissions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sub := i.ApplicationCommandData().Options[0].Options[0]
+	switch sub.Name {
+	case "grant", "revoke":
+		content, err := h.changeCapability(i, sub)
+		h.recordAudit(i, "", err)
+		if err != nil {
+			doFailedInteraction(s, i, err.Error())
+			return
+		}
+
+		respondEphemeral(s, i, content)
+	case "list":
+		capabilities, err := h.gringotts.ListRoleCapabilities(context.Background(), i.GuildID)
+		if err != nil {
+			doFailedInteraction(s, i, fmt.Sprintf("unable to list capabilities: %v", err))
+			return
+		}
+
+		lines := []string{fmt.Sprintf("everyone: %s", strings.Join(h.defaultCapabilities, ", "))}
+		for _, c := range capabilities {
+			lines = append(lines, fmt.Sprintf("<@&%s>: %s", c.RoleID, c.Capability))
+		}
+
+		respondEphemeral(s, i, truncateLines(lines, maxContentLength))
+	default:
+		doFailedInteraction(s, i, fmt.Sprintf("unknown subcommand %s", sub.Name))
+	}
+}
+
+// changeCapability grants or revokes a capability as requested by sub.
+func (h *Handler) changeCapability(i *discordgo.InteractionCreate, sub *discordgo.ApplicationCommandInteractionDataOption) (string, error) {
+	var roleID, capability string
+	for _, opt := range sub.Options {
+		switch opt.Name {
+		case "role":
+			roleID = opt.RoleValue(nil, i.GuildID).ID
+		case "capability":
+			capability = opt.StringValue()
+		}
+	}
+
+	if _, ok := capabilityLevels[capability]; !ok {
+		return "", fmt.Errorf("unknown capability %s", capability)
+	}
+
+	if sub.Name == "grant" {
+		err := h.gringotts.GrantCapability(context.Background(), i.GuildID, roleID, capability)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("granted %s to <@&%s>", capability, roleID), nil
+	}
+
+	revoked, err := h.gringotts.RevokeCapability(context.Background(), i.GuildID, roleID, capability)
+	if err != nil {
+		return "", err
+	}
+
+	if !revoked {
+		return fmt.Sprintf("<@&%s> does not have %s", roleID, capability), nil
+	}
+
+	return fmt.Sprintf("revoked %s from <@&%s>", capability, roleID), nil
+}
+
+// respondEphemeral replies to i with a message only the caller can see.
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := respond(s, i, &discordgo.InteractionResponseData{
+		Content:         content,
+		Flags:           discordgo.MessageFlagsEphemeral,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		doFailedInteraction(s, i, err.Error())
+		return
+	}
+}
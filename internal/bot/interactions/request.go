@@ -0,0 +1,423 @@
+package interactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+const (
+	// requestComponentPrefix marks the buttons officers use to approve, deny
+	// or fulfill a request, the custom ID carries the new status.
+	requestComponentPrefix = "request"
+	// cancelRequestComponentPrefix marks the button requesters use to cancel
+	// their own request.
+	cancelRequestComponentPrefix = "cancelrequest"
+
+	// maxNoteLength is the longest note accepted with a request.
+	maxNoteLength = 200
+
+	// requestResultLimit is the most requests listed by /gbank requests.
+	requestResultLimit = 25
+)
+
+// requestColors are the embed colors of requests in each status.
+var requestColors = map[string]int{
+	database.RequestOpen:      0x3498db,
+	database.RequestApproved:  0x2ecc71,
+	database.RequestDenied:    0xe74c3c,
+	database.RequestFulfilled: 0x95a5a6,
+	database.RequestCancelled: 0x95a5a6,
+}
+
+var minRequestQuantity = 1.0
+
+var requestSubCommand = &discordgo.ApplicationCommandOption{
+	Name:        "request",
+	Description: "ask the officers for items from the bank",
+	Type:        discordgo.ApplicationCommandOptionSubCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "item",
+			Description:  "name of the item",
+			Required:     true,
+			Autocomplete: true,
+			MaxLength:    maxSearchLength,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "quantity",
+			Description: "how many you need",
+			Required:    true,
+			MinValue:    &minRequestQuantity,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "note",
+			Description: "what the items are for",
+			MaxLength:   maxNoteLength,
+		},
+	},
+}
+
+var requestsSubCommand = &discordgo.ApplicationCommandOption{
+	Name:        "requests",
+	Description: "list item requests",
+	Type:        discordgo.ApplicationCommandOptionSubCommand,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "status",
+			Description: "only list requests in this status",
+			Choices:     requestStatusChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "only list requests made by this user",
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "item",
+			Description:  "only list requests for this item",
+			Autocomplete: true,
+			MaxLength:    maxSearchLength,
+		},
+	},
+}
+
+func requestStatusChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, s := range database.RequestStatuses {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: s, Value: s})
+	}
+
+	return choices
+}
+
+// RequestItem posts a new item request with buttons for officers to approve or
+// deny it.
+func (h *Handler) RequestItem(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var itemName, note string
+	var quantity int
+	for _, opt := range i.ApplicationCommandData().Options[0].Options {
+		switch opt.Name {
+		case "item":
+			itemName = strings.TrimSpace(opt.StringValue())
+		case "quantity":
+			quantity = int(opt.IntValue())
+		case "note":
+			note = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	item, err := h.exactItem(i.GuildID, itemName)
+	if err != nil {
+		doFailedInteraction(s, i, err.Error())
+		return
+	}
+
+	user := interactionUser(i)
+	if user == nil {
+		doFailedInteraction(s, i, "unable to tell who made the request")
+		return
+	}
+
+	req, err := h.gringotts.CreateRequest(context.Background(), i.GuildID, item.ID, quantity, note, user.ID)
+	if err != nil {
+		doFailedInteraction(s, i, fmt.Sprintf("unable to store the request: %v", err))
+		return
+	}
+
+	err = respond(s, i, h.requestMessage(i.GuildID, req))
+	if err != nil {
+		doFailedInteraction(s, i, err.Error())
+		return
+	}
+
+	// remember where the request was posted so it can be updated later
+	m, err := s.InteractionResponse(i.Interaction)
+	if err != nil {
+		slog.Warn("unable to read request message", "err", err)
+		return
+	}
+
+	err = h.gringotts.SetRequestMessage(context.Background(), i.GuildID, req.ID, m.ChannelID, m.ID)
+	if err != nil {
+		slog.Error("unable to store request message", "err", err)
+	}
+}
+
+// exactItem returns the item named name, ignoring case, with its counts in
+// guildID.
+func (h *Handler) exactItem(guildID, name string) (*database.Item, error) {
+	items, err := h.gringotts.FindItem(context.Background(), guildID, name, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find item: %v", err)
+	}
+
+	if len(items) == 0 || !strings.EqualFold(items[0].Name, name) {
+		return nil, fmt.Errorf("no item named %s found", name)
+	}
+
+	return items[0], nil
+}
+
+// requestMessage renders req along with the buttons for the status changes it
+// allows.
+func (h *Handler) requestMessage(guildID string, req *database.ItemRequest) *discordgo.InteractionResponseData {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("request #%d", req.ID),
+		Description: h.requestedItems(guildID, req),
+		Color:       requestColors[req.Status],
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "requested by", Value: fmt.Sprintf("<@%s>", req.RequesterID), Inline: true},
+			{Name: "status", Value: requestStatus(req), Inline: true},
+		},
+	}
+
+	if req.Note != "" {
+		embed.Description += "\n" + req.Note
+	}
+
+	if req.Status == database.RequestOpen || req.Status == database.RequestApproved {
+		items, err := h.gringotts.FindItem(context.Background(), guildID, req.ItemName, 1, 0)
+		if err != nil {
+			slog.Warn("unable to find requested item", "item", req.ItemID, "err", err)
+		}
+
+		if len(items) > 0 && items[0].ID == req.ItemID {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:   "in the bank",
+				Value:  fmt.Sprint(items[0].Count),
+				Inline: true,
+			})
+		}
+	}
+
+	var buttons []discordgo.MessageComponent
+	for _, b := range []struct {
+		status string
+		label  string
+		style  discordgo.ButtonStyle
+	}{
+		{status: database.RequestApproved, label: "Approve", style: discordgo.SuccessButton},
+		{status: database.RequestDenied, label: "Deny", style: discordgo.DangerButton},
+		{status: database.RequestFulfilled, label: "Mark fulfilled", style: discordgo.PrimaryButton},
+	} {
+		if req.CanBecome(b.status) {
+			buttons = append(buttons, discordgo.Button{
+				Label:    b.label,
+				Style:    b.style,
+				CustomID: requestCustomID(b.status, req.ID),
+			})
+		}
+	}
+
+	if req.CanBecome(database.RequestCancelled) {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Cancel",
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("%s:%d", cancelRequestComponentPrefix, req.ID),
+		})
+	}
+
+	data := &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		// always set so settled requests lose their buttons
+		Components:      []discordgo.MessageComponent{},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+
+	if len(buttons) > 0 {
+		data.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+	}
+
+	return data
+}
+
+// requestedItems renders the quantity and link of the item asked for in req.
+func (h *Handler) requestedItems(guildID string, req *database.ItemRequest) string {
+	return fmt.Sprintf("%d x %s", req.Quantity, h.links.Markdown(req.ItemName, req.ItemID, h.links.Flavor(guildID)))
+}
+
+// requestStatus describes the status of req and who decided it.
+func requestStatus(req *database.ItemRequest) string {
+	if req.DecidedBy == "" {
+		return req.Status
+	}
+
+	return fmt.Sprintf("%s by <@%s>", req.Status, req.DecidedBy)
+}
+
+// requestCustomID encodes a change of request id to status in a button custom
+// ID.
+func requestCustomID(status string, id int64) string {
+	return fmt.Sprintf("%s:%s:%d", requestComponentPrefix, status, id)
+}
+
+// parseRequestCustomID decodes a custom ID created by requestCustomID.
+func parseRequestCustomID(customID string) (string, int64, error) {
+	parts := strings.Split(customID, ":")
+	if len(parts) != 3 || parts[0] != requestComponentPrefix {
+		return "", 0, fmt.Errorf("invalid request custom id %s", customID)
+	}
+
+	switch parts[1] {
+	case database.RequestApproved, database.RequestDenied, database.RequestFulfilled:
+	default:
+		return "", 0, fmt.Errorf("invalid request status in custom id %s", customID)
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid request in custom id %s", customID)
+	}
+
+	return parts[1], id, nil
+}
+
+// parseCancelRequestCustomID decodes the custom ID of a cancel button.
+func parseCancelRequestCustomID(customID string) (int64, error) {
+	prefix, idStr, _ := strings.Cut(customID, ":")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if prefix != cancelRequestComponentPrefix || err != nil {
+		return 0, fmt.Errorf("invalid cancel request custom id %s", customID)
+	}
+
+	return id, nil
+}
+
+// ChangeRequest handles the buttons of a posted request. Only officers get
+// here for approve, deny and fulfill, cancelling is open to the requester too.
+func (h *Handler) ChangeRequest(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	req, err := h.changeRequest(i)
+	h.recordAudit(i, "", err)
+	if err != nil {
+		doFailedInteraction(s, i, err.Error())
+		return
+	}
+
+	err = respond(s, i, h.requestMessage(i.GuildID, req))
+	if err != nil {
+		doFailedInteraction(s, i, err.Error())
+		return
+	}
+}
+
+// changeRequest applies the status change asked for by the button pressed in
+// i.
+func (h *Handler) changeRequest(i *discordgo.InteractionCreate) (*database.ItemRequest, error) {
+	customID := i.MessageComponentData().CustomID
+
+	user := interactionUser(i)
+	if user == nil {
+		return nil, errors.New("unable to tell who pressed the button")
+	}
+
+	var status string
+	var id int64
+	var err error
+	if strings.HasPrefix(customID, cancelRequestComponentPrefix+":") {
+		status = database.RequestCancelled
+		id, err = parseCancelRequestCustomID(customID)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := h.gringotts.GetRequest(context.Background(), i.GuildID, id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkCancel(req, user.ID, func() (bool, error) {
+			return h.authorized(i, database.CapabilityAdmin)
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		status, id, err = parseRequestCustomID(customID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return h.gringotts.UpdateRequestStatus(context.Background(), i.GuildID, id, status, user.ID)
+}
+
+// checkCancel returns an error unless userID may cancel req. Requesters may
+// cancel their own requests, officer reports whether the user is an officer,
+// who may cancel any.
+func checkCancel(req *database.ItemRequest, userID string, officer func() (bool, error)) error {
+	if req.RequesterID == userID {
+		return nil
+	}
+
+	ok, err := officer()
+	if err != nil {
+		return fmt.Errorf("unable to check permissions: %v", err)
+	}
+
+	if !ok {
+		return errors.New("only the requester or an officer can cancel a request")
+	}
+
+	return nil
+}
+
+// Requests lists the item requests of the guild.
+func (h *Handler) Requests(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	filter := database.RequestFilter{Limit: requestResultLimit}
+	for _, opt := range i.ApplicationCommandData().Options[0].Options {
+		switch opt.Name {
+		case "status":
+			filter.Status = opt.StringValue()
+		case "user":
+			filter.RequesterID = opt.UserValue(nil).ID
+		case "item":
+			item, err := h.exactItem(i.GuildID, strings.TrimSpace(opt.StringValue()))
+			if err != nil {
+				doFailedInteraction(s, i, err.Error())
+				return
+			}
+			filter.ItemID = item.ID
+		}
+	}
+
+	requests, err := h.gringotts.ListRequests(context.Background(), i.GuildID, filter)
+	if err != nil {
+		doFailedInteraction(s, i, fmt.Sprintf("unable to list requests: %v", err))
+		return
+	}
+
+	lines := []string{}
+	for _, r := range requests {
+		line := fmt.Sprintf("#%d <t:%d:d> <@%s> asked for %s: %s", r.ID, r.CreatedAt.Unix(), r.RequesterID, h.requestedItems(i.GuildID, r), requestStatus(r))
+		if r.Note != "" {
+			line += fmt.Sprintf(" (%s)", r.Note)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "no matching requests")
+	}
+
+	err = respond(s, i, &discordgo.InteractionResponseData{
+		Content:         truncateLines(lines, maxContentLength),
+		Flags:           discordgo.MessageFlagsEphemeral | discordgo.MessageFlagsSuppressEmbeds,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		doFailedInteraction(s, i, err.Error())
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package interactions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequestCustomID(t *testing.T) {
+	for _, tc := range []struct {
+		customID string
+		status   string
+		id       int64
+		err      bool
+	}{
+		{customID: requestCustomID(database.RequestApproved, 12), status: database.RequestApproved, id: 12},
+		{customID: requestCustomID(database.RequestDenied, 1), status: database.RequestDenied, id: 1},
+		{customID: requestCustomID(database.RequestFulfilled, 7), status: database.RequestFulfilled, id: 7},
+		// cancelling has its own button open to requesters
+		{customID: requestCustomID(database.RequestCancelled, 7), err: true},
+		{customID: requestCustomID(database.RequestOpen, 7), err: true},
+		{customID: "request:approved", err: true},
+		{customID: "request:approved:x", err: true},
+		{customID: "request:approved:1:2", err: true},
+		{customID: "cancelrequest:approved:1", err: true},
+		{customID: "search:0:0:flask", err: true},
+	} {
+		status, id, err := parseRequestCustomID(tc.customID)
+		if tc.err {
+			require.Error(t, err, tc.customID)
+			continue
+		}
+
+		require.NoError(t, err, tc.customID)
+		require.Equal(t, tc.status, status, tc.customID)
+		require.Equal(t, tc.id, id, tc.customID)
+	}
+}
+
+func TestParseCancelRequestCustomID(t *testing.T) {
+	for _, tc := range []struct {
+		customID string
+		id       int64
+		err      bool
+	}{
+		{customID: "cancelrequest:12", id: 12},
+		{customID: "cancelrequest:", err: true},
+		{customID: "cancelrequest:x", err: true},
+		{customID: "cancelrequest:1:2", err: true},
+		{customID: "request:12", err: true},
+	} {
+		id, err := parseCancelRequestCustomID(tc.customID)
+		if tc.err {
+			require.Error(t, err, tc.customID)
+			continue
+		}
+
+		require.NoError(t, err, tc.customID)
+		require.Equal(t, tc.id, id, tc.customID)
+	}
+}
+
+func TestCheckCancel(t *testing.T) {
+	req := &database.ItemRequest{ID: 1, RequesterID: "100", Status: database.RequestOpen}
+
+	officer := func(ok bool, err error) func() (bool, error) {
+		return func() (bool, error) { return ok, err }
+	}
+
+	for _, tc := range []struct {
+		name    string
+		userID  string
+		officer func() (bool, error)
+		err     string
+	}{
+		{name: "requester", userID: "100", officer: officer(false, errors.New("not asked"))},
+		{name: "officer", userID: "200", officer: officer(true, nil)},
+		{name: "other member", userID: "300", officer: officer(false, nil), err: "only the requester or an officer"},
+		{name: "permission error", userID: "300", officer: officer(false, errors.New("boom")), err: "unable to check permissions: boom"},
+	} {
+		err := checkCancel(req, tc.userID, tc.officer)
+		if tc.err == "" {
+			require.NoError(t, err, tc.name)
+		} else {
+			require.ErrorContains(t, err, tc.err, tc.name)
+		}
+	}
+}
+
+func TestRequiredCapability_RequestButtons(t *testing.T) {
+	component := func(customID string) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{CustomID: customID},
+		}}
+	}
+
+	for _, status := range []string{database.RequestApproved, database.RequestDenied, database.RequestFulfilled} {
+		require.Equal(t, database.CapabilityAdmin, requiredCapability(component(requestCustomID(status, 1))), status)
+	}
+
+	require.Equal(t, database.CapabilityRead, requiredCapability(component("cancelrequest:1")))
+}
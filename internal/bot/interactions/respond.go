@@ -0,0 +1,107 @@
+package interactions
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// deferredCommands are the commands that may take longer than the three
+// seconds Discord allows for a response, mapped to the flags of the eventual
+// reply. They are acknowledged up front and answered by editing the response.
+var deferredCommands = map[string]discordgo.MessageFlags{
+	"find-item":      0,
+	"gbank search":   0,
+	"gbank sniff":    discordgo.MessageFlagsSuppressEmbeds,
+	"gbank browse":   0,
+	"gbank audit":    discordgo.MessageFlagsEphemeral,
+	"load-inventory": 0,
+}
+
+// deferredComponents are the message components, keyed by custom ID prefix,
+// that are acknowledged up front and answered by editing the message.
+var deferredComponents = map[string]bool{
+	searchComponentPrefix: true,
+	browseComponentPrefix: true,
+}
+
+// isDeferred reports whether the response to i is deferred, along with the
+// flags of the reply.
+func isDeferred(i *discordgo.InteractionCreate) (discordgo.MessageFlags, bool) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		data := i.ApplicationCommandData()
+		command, _ := commandPath(data.Name, data.Options)
+		flags, ok := deferredCommands[command]
+		return flags, ok
+	case discordgo.InteractionMessageComponent:
+		prefix, _, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+		return 0, deferredComponents[prefix]
+	default:
+		return 0, false
+	}
+}
+
+// deferResponse acknowledges i if its response is deferred, it does nothing
+// for interactions that are answered immediately.
+func deferResponse(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	flags, deferred := isDeferred(i)
+	if !deferred {
+		return
+	}
+
+	responseType := discordgo.InteractionResponseDeferredChannelMessageWithSource
+	if i.Type == discordgo.InteractionMessageComponent {
+		responseType = discordgo.InteractionResponseDeferredMessageUpdate
+	}
+
+	err := s.InteractionRespond(
+		i.Interaction,
+		&discordgo.InteractionResponse{
+			Type: responseType,
+			Data: &discordgo.InteractionResponseData{
+				Flags: flags,
+			},
+		},
+	)
+	if err != nil {
+		slog.Error("unable to defer response", "err", err)
+	}
+}
+
+// respond answers i with data, editing the deferred response when there is
+// one. Component interactions update the message the component is attached to.
+func respond(s *discordgo.Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) error {
+	if _, deferred := isDeferred(i); deferred {
+		edit := &discordgo.WebhookEdit{
+			Content:         &data.Content,
+			AllowedMentions: data.AllowedMentions,
+		}
+
+		if data.Embeds != nil {
+			edit.Embeds = &data.Embeds
+		}
+
+		if data.Components != nil {
+			edit.Components = &data.Components
+		}
+
+		_, err := s.InteractionResponseEdit(i.Interaction, edit)
+
+		return err
+	}
+
+	responseType := discordgo.InteractionResponseChannelMessageWithSource
+	if i.Type == discordgo.InteractionMessageComponent {
+		responseType = discordgo.InteractionResponseUpdateMessage
+	}
+
+	return s.InteractionRespond(
+		i.Interaction,
+		&discordgo.InteractionResponse{
+			Type: responseType,
+			Data: data,
+		},
+	)
+}
@@ -0,0 +1,330 @@
+package interactions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+const (
+	// searchPageSize is the number of items shown on each page of results.
+	searchPageSize = 10
+
+	// maxSearchLength keeps the search string short enough to be carried in
+	// the custom ID of the paging buttons, which Discord caps at 100
+	// characters.
+	maxSearchLength = 80
+
+	// maxHolders is the most holders listed for a single item.
+	maxHolders = 10
+
+	searchComponentPrefix = "search"
+	searchEmbedColor      = 0x3498db
+)
+
+// minQualityOption narrows a search down to items of at least a quality.
+var minQualityOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionInteger,
+	Name:        "min-quality",
+	Description: "only show items of at least this quality",
+	Choices:     qualityChoices(),
+}
+
+// qualityChoices are the item qualities, from poor to heirloom.
+func qualityChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for q := database.QualityPoor; q <= database.QualityHeirloom; q++ {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  database.QualityName(q),
+			Value: q,
+		})
+	}
+
+	return choices
+}
+
+// searchOptions reads the item name and minimum quality of a search command.
+func searchOptions(opts []*discordgo.ApplicationCommandInteractionDataOption) (string, int) {
+	var name string
+	minQuality := database.QualityPoor
+	for _, opt := range opts {
+		switch opt.Name {
+		case "name", "item-name":
+			name = opt.StringValue()
+		case "min-quality":
+			minQuality = int(opt.IntValue())
+		}
+	}
+
+	return name, minQuality
+}
+
+// searchPage runs a search for items of at least minQuality and renders the
+// requested zero based page of results, one embed per quality colored like the
+// item names in game, best quality first.
+func (h *Handler) searchPage(guildID, searchString string, minQuality, page int) (*discordgo.InteractionResponseData, error) {
+	// fetch one extra item to know whether there is a next page
+	result, err := h.gringotts.SearchItems(context.Background(), guildID, searchString, minQuality, searchPageSize+1, page*searchPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	hasNext := len(result.Items) > searchPageSize
+	if hasNext {
+		result.Items = result.Items[:searchPageSize]
+	}
+
+	title := fmt.Sprintf("search results for %s", searchString)
+	if minQuality > database.QualityPoor {
+		title += fmt.Sprintf(" (%s or better)", database.QualityName(minQuality))
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	if len(result.Items) > 0 {
+		embeds = h.qualityEmbeds(guildID, result.Items)
+		embeds[len(embeds)-1].Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("page %d", page+1)}
+	} else {
+		embed := &discordgo.MessageEmbed{
+			Title:       title,
+			Color:       searchEmbedColor,
+			Description: fmt.Sprintf("no items matching %s found", searchString),
+		}
+
+		if len(result.Suggestions) > 0 && h.features.SearchSuggestions {
+			embed.Description = fmt.Sprintf("no items matching %s found, did you mean %s?", searchString, strings.Join(result.Suggestions, ", "))
+		}
+
+		embeds = append(embeds, embed)
+		title = ""
+	}
+
+	data := &discordgo.InteractionResponseData{
+		Content: title,
+		Embeds:  embeds,
+		// always set so paging to a page without buttons removes them
+		Components: []discordgo.MessageComponent{},
+	}
+
+	if page > 0 || hasNext {
+		data.Components = pageButtons(page, hasNext, func(page int) string {
+			return searchCustomID(page, minQuality, searchString)
+		})
+	}
+
+	return data, nil
+}
+
+// qualityEmbeds renders items in one embed per quality, best quality first,
+// keeping the order of items of the same quality.
+func (h *Handler) qualityEmbeds(guildID string, items []*database.Item) []*discordgo.MessageEmbed {
+	sorted := append([]*database.Item(nil), items...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return sorted[a].Quality > sorted[b].Quality
+	})
+
+	var embeds []*discordgo.MessageEmbed
+	for k, i := range sorted {
+		if k == 0 || i.Quality != sorted[k-1].Quality {
+			embeds = append(embeds, &discordgo.MessageEmbed{
+				Title: database.QualityName(i.Quality),
+				Color: database.QualityColor(i.Quality),
+			})
+		}
+
+		embed := embeds[len(embeds)-1]
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  i.Name,
+			Value: h.itemFieldValue(guildID, i),
+		})
+	}
+
+	return embeds
+}
+
+// pageButtons returns the previous and next buttons of a paged message, with
+// customID encoding the page each button shows.
+func pageButtons(page int, hasNext bool, customID func(page int) string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					Disabled: page == 0,
+					CustomID: customID(page - 1),
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					Disabled: !hasNext,
+					CustomID: customID(page + 1),
+				},
+			},
+		},
+	}
+}
+
+// itemFieldValue renders the link, total and holders of an item in guildID for
+// an embed field. The link follows the flavor of the holders.
+func (h *Handler) itemFieldValue(guildID string, i *database.Item) string {
+	holders := " not in the bank"
+	if len(i.Holders) > 0 {
+		holders = "\n" + holderLines(i.Holders)
+	}
+
+	var owners []database.Owner
+	for _, holder := range i.Holders {
+		owners = append(owners, holder.Owner)
+	}
+
+	link := h.links.Markdown("", i.ID, h.links.Flavor(guildID, owners...))
+
+	total := fmt.Sprint(i.Count)
+	if i.Info != nil {
+		if category := i.Info.Category(); category != "" {
+			link += " · " + category
+		}
+
+		if i.Info.StackSize > 1 && i.Count > 0 {
+			total = fmt.Sprintf("%d (%d stacks of %d)", i.Count, i.Info.Stacks(i.Count), i.Info.StackSize)
+		}
+	}
+
+	return fmt.Sprintf("%s\ntotal: %s\nheld by:%s", link, total, holders)
+}
+
+// holderLines renders holders one line per realm and faction, such as
+// "Mankrik (horde): Gbank 20, Gbank2 5". Holders are expected in the order
+// returned by the database and at most maxHolders are listed.
+func holderLines(holders []*database.Holder) string {
+	var lines []string
+	var group string
+	var names []string
+
+	flush := func() {
+		if len(names) == 0 {
+			return
+		}
+
+		line := strings.Join(names, ", ")
+		if group != "" {
+			line = group + ": " + line
+		}
+
+		lines = append(lines, line)
+		names = nil
+	}
+
+	for k, holder := range holders {
+		if k == maxHolders {
+			flush()
+			lines = append(lines, fmt.Sprintf("and %d more", len(holders)-k))
+			break
+		}
+
+		if g := holderGroup(holder.Owner); g != group || k == 0 {
+			flush()
+			group = g
+		}
+
+		names = append(names, fmt.Sprintf("%s %d", holder.Owner.Name, holder.Count))
+	}
+
+	flush()
+
+	return strings.Join(lines, "\n")
+}
+
+// holderGroup labels the realm and faction of owner, empty when neither is
+// known.
+func holderGroup(owner database.Owner) string {
+	switch {
+	case owner.Realm == "" && owner.Faction == "":
+		return ""
+	case owner.Faction == "":
+		return owner.Realm
+	case owner.Realm == "":
+		return "(" + owner.Faction + ")"
+	default:
+		return fmt.Sprintf("%s (%s)", owner.Realm, owner.Faction)
+	}
+}
+
+// searchCustomID encodes a page of a search in a button custom ID.
+func searchCustomID(page, minQuality int, searchString string) string {
+	return fmt.Sprintf("%s:%d:%d:%s", searchComponentPrefix, page, minQuality, searchString)
+}
+
+// parseSearchCustomID decodes a custom ID created by searchCustomID.
+func parseSearchCustomID(customID string) (int, int, string, error) {
+	parts := strings.SplitN(customID, ":", 4)
+	if len(parts) != 4 || parts[0] != searchComponentPrefix {
+		return 0, 0, "", fmt.Errorf("invalid search custom id %s", customID)
+	}
+
+	page, err := strconv.Atoi(parts[1])
+	if err != nil || page < 0 {
+		return 0, 0, "", fmt.Errorf("invalid search page in custom id %s", customID)
+	}
+
+	minQuality, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid search quality in custom id %s", customID)
+	}
+
+	return page, minQuality, parts[3], nil
+}
+
+// HandleComponent handles button presses on messages sent by the bot.
+func (h *Handler) HandleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	prefix, _, _ := strings.Cut(customID, ":")
+
+	switch prefix {
+	case searchComponentPrefix:
+		page, minQuality, searchString, err := parseSearchCustomID(customID)
+		if err != nil {
+			doFailedInteraction(s, i, err.Error())
+			return
+		}
+
+		data, err := h.searchPage(i.GuildID, searchString, minQuality, page)
+		if err != nil {
+			doFailedInteraction(s, i, fmt.Sprintf("unable to find item: %v", err))
+			return
+		}
+
+		err = respond(s, i, data)
+		if err != nil {
+			doFailedInteraction(s, i, err.Error())
+			return
+		}
+	case requestComponentPrefix, cancelRequestComponentPrefix:
+		h.ChangeRequest(s, i)
+	case browseComponentPrefix:
+		page, classID, subclass, err := parseBrowseCustomID(customID)
+		if err != nil {
+			doFailedInteraction(s, i, err.Error())
+			return
+		}
+
+		data, err := h.browsePage(i.GuildID, classID, subclass, page)
+		if err != nil {
+			doFailedInteraction(s, i, fmt.Sprintf("unable to browse the bank: %v", err))
+			return
+		}
+
+		err = respond(s, i, data)
+		if err != nil {
+			doFailedInteraction(s, i, err.Error())
+			return
+		}
+	default:
+		doFailedInteraction(s, i, fmt.Sprintf("unknown component %s", customID))
+	}
+}
@@ -0,0 +1,243 @@
+// Package catalog reads item metadata dumps into database.ItemInfo.
+//
+// A dump is either CSV with a header row or a JSON array of objects, with one
+// item per row or object. Column and key names are matched ignoring case, and
+// the common names used by database exports are accepted as aliases:
+//
+//	id             itemID, entry
+//	name           itemName
+//	quality        rarity, either the number or a name such as epic
+//	class          classID
+//	className
+//	subclass       subclassID
+//	subclassName
+//	itemLevel      ilvl, level
+//	requiredLevel  reqLevel, minLevel
+//	stackSize      stackable, maxStack
+//	vendorPrice    sellPrice, in copper
+//	binding        bonding, either the number or pickup, equip, use or quest
+//
+// Only id is required.
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// aliases fold the accepted column names into the canonical ones.
+var aliases = map[string]string{
+	"id":            "id",
+	"itemid":        "id",
+	"entry":         "id",
+	"name":          "name",
+	"itemname":      "name",
+	"quality":       "quality",
+	"rarity":        "quality",
+	"class":         "class",
+	"classid":       "class",
+	"classname":     "classname",
+	"subclass":      "subclass",
+	"subclassid":    "subclass",
+	"subclassname":  "subclassname",
+	"itemlevel":     "itemlevel",
+	"ilvl":          "itemlevel",
+	"level":         "itemlevel",
+	"requiredlevel": "requiredlevel",
+	"reqlevel":      "requiredlevel",
+	"minlevel":      "requiredlevel",
+	"stacksize":     "stacksize",
+	"stackable":     "stacksize",
+	"maxstack":      "stacksize",
+	"vendorprice":   "vendorprice",
+	"sellprice":     "vendorprice",
+	"binding":       "binding",
+	"bonding":       "binding",
+}
+
+var bindings = map[string]int{
+	"none":   database.BindNone,
+	"pickup": database.BindOnPickup,
+	"equip":  database.BindOnEquip,
+	"use":    database.BindOnUse,
+	"quest":  database.BindQuest,
+}
+
+// Parse reads a CSV or JSON dump.
+func Parse(data []byte) ([]*database.ItemInfo, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, errors.New("the dump is empty")
+	}
+
+	if trimmed[0] == '[' {
+		return parseJSON(trimmed)
+	}
+
+	return parseCSV(trimmed)
+}
+
+func parseJSON(data []byte) ([]*database.ItemInfo, error) {
+	var rows []map[string]any
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	var items []*database.ItemInfo
+	for k, row := range rows {
+		fields := map[string]string{}
+		for key, v := range row {
+			name, ok := aliases[strings.ToLower(key)]
+			if !ok {
+				continue
+			}
+
+			switch v := v.(type) {
+			case string:
+				fields[name] = v
+			case float64:
+				fields[name] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				// stackable is sometimes a flag rather than a size
+				if v {
+					fields[name] = "1"
+				}
+			}
+		}
+
+		item, err := parseItem(fields)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", k+1, err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func parseCSV(data []byte) ([]*database.ItemInfo, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("no header row")
+	}
+
+	columns := map[string]int{}
+	for k, v := range records[0] {
+		if name, ok := aliases[strings.ToLower(strings.TrimSpace(v))]; ok {
+			columns[name] = k
+		}
+	}
+
+	if _, ok := columns["id"]; !ok {
+		return nil, errors.New("the header has no id column")
+	}
+
+	var items []*database.ItemInfo
+	for k, record := range records[1:] {
+		fields := map[string]string{}
+		for name, column := range columns {
+			if column < len(record) {
+				fields[name] = record[column]
+			}
+		}
+
+		item, err := parseItem(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", k+2, err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// parseItem converts the canonical fields of one item.
+func parseItem(fields map[string]string) (*database.ItemInfo, error) {
+	i := &database.ItemInfo{
+		ID:           strings.TrimSpace(fields["id"]),
+		Name:         strings.TrimSpace(fields["name"]),
+		Quality:      database.QualityCommon,
+		ClassName:    strings.TrimSpace(fields["classname"]),
+		SubclassName: strings.TrimSpace(fields["subclassname"]),
+		StackSize:    1,
+	}
+
+	if _, err := strconv.Atoi(i.ID); err != nil {
+		return nil, fmt.Errorf("invalid item id %q", i.ID)
+	}
+
+	if v := strings.TrimSpace(fields["quality"]); v != "" {
+		q, err := strconv.Atoi(v)
+		if err != nil {
+			var ok bool
+			if q, ok = database.ParseQuality(v); !ok {
+				return nil, fmt.Errorf("invalid quality %q", v)
+			}
+		}
+
+		i.Quality = q
+	}
+
+	if v := strings.ToLower(strings.TrimSpace(fields["binding"])); v != "" {
+		b, err := strconv.Atoi(v)
+		if err != nil {
+			var ok bool
+			if b, ok = bindings[strings.TrimPrefix(strings.TrimPrefix(v, "bind on "), "bind_on_")]; !ok {
+				return nil, fmt.Errorf("invalid binding %q", v)
+			}
+		}
+
+		i.Binding = b
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  *int
+	}{
+		{name: "class", dst: &i.ClassID},
+		{name: "subclass", dst: &i.SubclassID},
+		{name: "itemlevel", dst: &i.ItemLevel},
+		{name: "requiredlevel", dst: &i.RequiredLevel},
+		{name: "stacksize", dst: &i.StackSize},
+	} {
+		v := strings.TrimSpace(fields[f.name])
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s %q", f.name, v)
+		}
+
+		*f.dst = n
+	}
+
+	if v := strings.TrimSpace(fields["vendorprice"]); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid vendorprice %q", v)
+		}
+
+		i.VendorPrice = n
+	}
+
+	return i, nil
+}
@@ -0,0 +1,69 @@
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/catalog"
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_CSV(t *testing.T) {
+	items, err := catalog.Parse([]byte(`# exported from the classic database
+entry,name,Quality,class,subclass,subclassName,ilvl,reqLevel,stackable,sellPrice,bonding
+19019,"Thunderfury, Blessed Blade of the Windseeker",legendary,2,7,Sword,80,60,1,255355,1
+2589,Linen Cloth,1,7,5,Cloth,5,0,20,13,0
+`))
+	require.NoError(t, err)
+	require.Equal(t, []*database.ItemInfo{
+		{
+			ID:            "19019",
+			Name:          "Thunderfury, Blessed Blade of the Windseeker",
+			Quality:       database.QualityLegendary,
+			ClassID:       2,
+			SubclassID:    7,
+			SubclassName:  "Sword",
+			ItemLevel:     80,
+			RequiredLevel: 60,
+			StackSize:     1,
+			VendorPrice:   255355,
+			Binding:       database.BindOnPickup,
+		},
+		{
+			ID:           "2589",
+			Name:         "Linen Cloth",
+			Quality:      database.QualityCommon,
+			ClassID:      7,
+			SubclassID:   5,
+			SubclassName: "Cloth",
+			ItemLevel:    5,
+			StackSize:    20,
+			VendorPrice:  13,
+		},
+	}, items)
+
+	_, err = catalog.Parse([]byte("name,quality\nLinen Cloth,1\n"))
+	require.ErrorContains(t, err, "no id column")
+
+	_, err = catalog.Parse([]byte("id,quality\n2589,shiny\n"))
+	require.ErrorContains(t, err, `line 2: invalid quality "shiny"`)
+}
+
+func TestParse_JSON(t *testing.T) {
+	items, err := catalog.Parse([]byte(`[
+		{"itemID": 12360, "itemName": "Arcanite Bar", "rarity": 2, "className": "Trade Goods", "classID": 7, "maxStack": 20, "binding": "bind on equip"},
+		{"id": "6948", "name": "Hearthstone", "quality": "common", "bonding": "pickup"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "12360", items[0].ID)
+	require.Equal(t, database.QualityUncommon, items[0].Quality)
+	require.Equal(t, "Trade Goods", items[0].ClassName)
+	require.Equal(t, 20, items[0].StackSize)
+	require.Equal(t, database.BindOnEquip, items[0].Binding)
+	require.Equal(t, "Hearthstone", items[1].Name)
+	require.Equal(t, database.BindOnPickup, items[1].Binding)
+
+	_, err = catalog.Parse([]byte(`[{"name": "no id"}]`))
+	require.ErrorContains(t, err, `item 1: invalid item id ""`)
+}
@@ -0,0 +1,358 @@
+// Package config loads the bot configuration from a YAML file, environment
+// variables and command line flags, in increasing order of precedence.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/itemlink"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables overriding the configuration file. The first four
+// predate the configuration file and keep their names.
+const (
+	EnvAppID         = "APP_ID"
+	EnvToken         = "BOT_TOKEN"
+	EnvDBPath        = "DB_PATH"
+	EnvGuildIDs      = "SERVER_ID"
+	EnvLogLevel      = "LOG_LEVEL"
+	EnvWowheadFlavor = "WOWHEAD_FLAVOR"
+	EnvItemLinkSite  = "ITEM_LINK_SITE"
+	// EnvConfigFile names the configuration file when -config is not given.
+	EnvConfigFile = "GRINGOTTS_CONFIG"
+)
+
+type Config struct {
+	AppID string `yaml:"appID"`
+	Token string `yaml:"token"`
+	// GuildIDs are the Discord servers commands are registered in.
+	GuildIDs []string `yaml:"guildIDs"`
+	// GlobalCommands registers commands for every server the bot is in
+	// instead of only GuildIDs.
+	GlobalCommands bool   `yaml:"globalCommands"`
+	DBPath         string `yaml:"dbPath"`
+	LogLevel       string `yaml:"logLevel"`
+	// WowheadFlavor is the game flavor item links are for. It kept its name
+	// from before sites other than wowhead were supported.
+	WowheadFlavor string `yaml:"wowheadFlavor"`
+	// GuildFlavors overrides WowheadFlavor for single servers.
+	GuildFlavors map[string]string `yaml:"guildFlavors"`
+	// ItemLinkSite is the site item links point to, wowhead is used for
+	// flavors it does not cover.
+	ItemLinkSite string      `yaml:"itemLinkSite"`
+	Permissions  Permissions `yaml:"permissions"`
+	Features     Features    `yaml:"features"`
+	Requests     Requests    `yaml:"requests"`
+}
+
+type Permissions struct {
+	// DefaultCapabilities are held by every guild member regardless of role.
+	DefaultCapabilities []string `yaml:"defaultCapabilities"`
+}
+
+// Requests configures the item request workflow.
+type Requests struct {
+	// Channels are the channels of each server that uploads fulfilling
+	// approved requests are reported in. Servers without one do not check
+	// uploads against requests.
+	Channels map[string]string `yaml:"channels"`
+	// AutoFulfill marks the requests an upload fulfills as fulfilled instead
+	// of only proposing them to the officers.
+	AutoFulfill bool `yaml:"autoFulfill"`
+}
+
+// Features toggle optional behavior, all are enabled by default.
+type Features struct {
+	// Autocomplete suggests item names while typing search options.
+	Autocomplete bool `yaml:"autocomplete"`
+	// SearchSuggestions answers searches that match nothing with similar
+	// item names.
+	SearchSuggestions bool `yaml:"searchSuggestions"`
+	// AuditLog records every command in the audit log.
+	AuditLog bool `yaml:"auditLog"`
+}
+
+// Default returns the configuration used for anything not set.
+func Default() *Config {
+	return &Config{
+		LogLevel:      "info",
+		WowheadFlavor: database.FlavorClassic,
+		ItemLinkSite:  itemlink.SiteWowhead,
+		Permissions: Permissions{
+			DefaultCapabilities: []string{database.CapabilityRead},
+		},
+		Features: Features{
+			Autocomplete:      true,
+			SearchSuggestions: true,
+			AuditLog:          true,
+		},
+	}
+}
+
+// Decode reads YAML over c, rejecting unknown keys.
+func (c *Config) Decode(r io.Reader) error {
+	d := yaml.NewDecoder(r)
+	d.KnownFields(true)
+
+	err := d.Decode(c)
+	if errors.Is(err, io.EOF) {
+		// an empty file
+		return nil
+	}
+
+	return err
+}
+
+// LoadFile reads the configuration file at path over c.
+func (c *Config) LoadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Decode(bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ApplyEnv overrides c with the environment variables found by lookup.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) {
+	for name, field := range map[string]*string{
+		EnvAppID:         &c.AppID,
+		EnvToken:         &c.Token,
+		EnvDBPath:        &c.DBPath,
+		EnvLogLevel:      &c.LogLevel,
+		EnvWowheadFlavor: &c.WowheadFlavor,
+		EnvItemLinkSite:  &c.ItemLinkSite,
+	} {
+		if v, ok := lookup(name); ok {
+			*field = v
+		}
+	}
+
+	if v, ok := lookup(EnvGuildIDs); ok {
+		c.GuildIDs = splitList(v)
+	}
+}
+
+// Validate reports every problem with the settings used by all commands.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.DBPath == "" {
+		errs = append(errs, errors.New("dbPath is required"))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("logLevel %q is not one of debug, info, warn or error", c.LogLevel))
+	}
+
+	if !contains(database.Flavors, c.WowheadFlavor) {
+		errs = append(errs, fmt.Errorf("wowheadFlavor %q is not one of %s", c.WowheadFlavor, strings.Join(database.Flavors, ", ")))
+	}
+
+	for _, id := range sortedKeys(c.GuildFlavors) {
+		if !contains(database.Flavors, c.GuildFlavors[id]) {
+			errs = append(errs, fmt.Errorf("guildFlavors: %s: %q is not one of %s", id, c.GuildFlavors[id], strings.Join(database.Flavors, ", ")))
+		}
+	}
+
+	if !contains(itemlink.Sites, c.ItemLinkSite) {
+		errs = append(errs, fmt.Errorf("itemLinkSite %q is not one of %s", c.ItemLinkSite, strings.Join(itemlink.Sites, ", ")))
+	}
+
+	capabilities := []string{database.CapabilityRead, database.CapabilityUpload, database.CapabilityAdmin}
+	for _, capability := range c.Permissions.DefaultCapabilities {
+		if !contains(capabilities, capability) {
+			errs = append(errs, fmt.Errorf("permissions.defaultCapabilities: %q is not one of %s", capability, strings.Join(capabilities, ", ")))
+		}
+	}
+
+	for _, id := range c.GuildIDs {
+		if !isSnowflake(id) {
+			errs = append(errs, fmt.Errorf("guildIDs: %q is not a Discord ID", id))
+		}
+	}
+
+	for _, id := range sortedKeys(c.Requests.Channels) {
+		switch {
+		case !isSnowflake(id):
+			errs = append(errs, fmt.Errorf("requests.channels: %q is not a Discord ID", id))
+		case !isSnowflake(c.Requests.Channels[id]):
+			errs = append(errs, fmt.Errorf("requests.channels: %s: %q is not a Discord ID", id, c.Requests.Channels[id]))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateDiscord reports every problem Validate does, along with those with
+// the settings needed to connect to Discord.
+func (c *Config) ValidateDiscord() error {
+	errs := []error{c.Validate()}
+
+	switch {
+	case c.AppID == "":
+		errs = append(errs, errors.New("appID is required"))
+	case !isSnowflake(c.AppID):
+		errs = append(errs, fmt.Errorf("appID %q is not a Discord ID", c.AppID))
+	}
+
+	if c.Token == "" {
+		errs = append(errs, errors.New("token is required"))
+	}
+
+	if len(c.GuildIDs) == 0 && !c.GlobalCommands {
+		errs = append(errs, errors.New("guildIDs requires at least one server unless globalCommands is set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// CommandGuildIDs returns the servers to register commands in, a single empty
+// ID registering them globally.
+func (c *Config) CommandGuildIDs() []string {
+	if c.GlobalCommands {
+		return []string{""}
+	}
+
+	return c.GuildIDs
+}
+
+// Level returns the parsed log level, info if it is invalid.
+func (c *Config) Level() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.LogLevel))
+
+	return level
+}
+
+// Flags are the command line flags overriding the configuration.
+type Flags struct {
+	fs       *flag.FlagSet
+	path     string
+	appID    string
+	token    string
+	guildIDs string
+	dbPath   string
+	logLevel string
+	flavor   string
+	site     string
+}
+
+// RegisterFlags registers the configuration flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.path, "config", "", "configuration file, defaults to $"+EnvConfigFile)
+	fs.StringVar(&f.appID, "app-id", "", "Discord application ID")
+	fs.StringVar(&f.token, "token", "", "Discord bot token")
+	fs.StringVar(&f.guildIDs, "guild", "", "comma separated Discord server IDs")
+	fs.StringVar(&f.dbPath, "db", "", "database file")
+	fs.StringVar(&f.logLevel, "log-level", "", "debug, info, warn or error")
+	fs.StringVar(&f.flavor, "wowhead-flavor", "", "game flavor for item links")
+	fs.StringVar(&f.site, "item-link-site", "", "site item links point to")
+
+	return f
+}
+
+// Load returns the configuration from the defaults, the configuration file,
+// the environment and the flags set on the command line. It must be called
+// after the flags are parsed and does not validate.
+func (f *Flags) Load() (*Config, error) {
+	c := Default()
+
+	path := f.path
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+
+	if path != "" {
+		if err := c.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	c.ApplyEnv(os.LookupEnv)
+
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "app-id":
+			c.AppID = f.appID
+		case "token":
+			c.Token = f.token
+		case "guild":
+			c.GuildIDs = splitList(f.guildIDs)
+		case "db":
+			c.DBPath = f.dbPath
+		case "log-level":
+			c.LogLevel = f.logLevel
+		case "wowhead-flavor":
+			c.WowheadFlavor = f.flavor
+		case "item-link-site":
+			c.ItemLinkSite = f.site
+		}
+	})
+
+	return c, nil
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+// isSnowflake reports whether s looks like a Discord ID.
+func isSnowflake(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
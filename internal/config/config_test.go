@@ -0,0 +1,122 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Decode(t *testing.T) {
+	c := config.Default()
+	err := c.Decode(strings.NewReader(`
+appID: "1234"
+token: secret
+guildIDs: ["5678", "9012"]
+dbPath: /var/lib/gringotts/bank.db
+logLevel: debug
+wowheadFlavor: sod
+guildFlavors:
+  "9012": cata
+itemLinkSite: classicdb
+features:
+  autocomplete: false
+requests:
+  channels:
+    "5678": "3456"
+`))
+	require.NoError(t, err)
+
+	require.Equal(t, "1234", c.AppID)
+	require.Equal(t, []string{"5678", "9012"}, c.GuildIDs)
+	require.Equal(t, "sod", c.WowheadFlavor)
+	require.Equal(t, map[string]string{"9012": "cata"}, c.GuildFlavors)
+	require.Equal(t, "classicdb", c.ItemLinkSite)
+	require.Equal(t, map[string]string{"5678": "3456"}, c.Requests.Channels)
+	require.False(t, c.Requests.AutoFulfill)
+	require.False(t, c.Features.Autocomplete)
+	// unset values keep their defaults
+	require.True(t, c.Features.AuditLog)
+	require.Equal(t, []string{"read"}, c.Permissions.DefaultCapabilities)
+	require.NoError(t, c.ValidateDiscord())
+
+	c.GuildIDs = nil
+	require.ErrorContains(t, c.ValidateDiscord(), "guildIDs requires at least one server")
+
+	c.GlobalCommands = true
+	require.NoError(t, c.ValidateDiscord())
+	require.Equal(t, []string{""}, c.CommandGuildIDs())
+
+	err = config.Default().Decode(strings.NewReader("dbpath: bank.db\n"))
+	require.ErrorContains(t, err, "field dbpath not found")
+
+	require.NoError(t, config.Default().Decode(strings.NewReader("")))
+}
+
+func TestConfig_ApplyEnv(t *testing.T) {
+	c := config.Default()
+	c.DBPath = "file.db"
+
+	env := map[string]string{
+		config.EnvDBPath:   "env.db",
+		config.EnvGuildIDs: "1, 2,,3",
+	}
+	c.ApplyEnv(func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	})
+
+	require.Equal(t, "env.db", c.DBPath)
+	require.Equal(t, []string{"1", "2", "3"}, c.GuildIDs)
+	require.Equal(t, "info", c.LogLevel)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := config.Default()
+	c.LogLevel = "loud"
+	c.WowheadFlavor = "vanilla"
+	c.GuildFlavors = map[string]string{"1": "tbc"}
+	c.ItemLinkSite = "thottbot"
+	c.Permissions.DefaultCapabilities = []string{"read", "write"}
+	c.GuildIDs = []string{"guild"}
+	c.Requests.Channels = map[string]string{"1": "#requests"}
+
+	err := c.ValidateDiscord()
+	require.Error(t, err)
+	require.Equal(t, []string{
+		"dbPath is required",
+		`logLevel "loud" is not one of debug, info, warn or error`,
+		`wowheadFlavor "vanilla" is not one of classic, sod, hardcore, cata, retail`,
+		`guildFlavors: 1: "tbc" is not one of classic, sod, hardcore, cata, retail`,
+		`itemLinkSite "thottbot" is not one of wowhead, classicdb`,
+		`permissions.defaultCapabilities: "write" is not one of read, upload, admin`,
+		`guildIDs: "guild" is not a Discord ID`,
+		`requests.channels: 1: "#requests" is not a Discord ID`,
+		"appID is required",
+		"token is required",
+	}, strings.Split(err.Error(), "\n"))
+}
+
+func TestFlags_Load(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("dbPath: file.db\nlogLevel: warn\nwowheadFlavor: cata\n"), 0o600))
+
+	t.Setenv(config.EnvLogLevel, "error")
+	t.Setenv(config.EnvWowheadFlavor, "hardcore")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := config.RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-config", path, "-wowhead-flavor", "sod"}))
+
+	c, err := f.Load()
+	require.NoError(t, err)
+
+	// flags override the environment, which overrides the file
+	require.Equal(t, "file.db", c.DBPath)
+	require.Equal(t, "error", c.LogLevel)
+	require.Equal(t, "sod", c.WowheadFlavor)
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Outcomes recorded for an audited command.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntry records a single run of a bank mutating command.
+type AuditEntry struct {
+	ID        int64
+	CreatedAt time.Time
+	UserID    string
+	Username  string
+	GuildID   string
+	Command   string
+	// Options is the JSON encoded command options.
+	Options string
+	// Owner is the bank characters affected by the command, if any, as
+	// "Name-Realm" separated by ", ".
+	Owner   string
+	Outcome string
+	// Message holds the error for failed commands.
+	Message string
+}
+
+// AuditFilter narrows the entries returned by FindAuditEntries. Zero valued
+// fields are ignored.
+type AuditFilter struct {
+	UserID string
+	// Owner matches entries affecting a bank character, given as "Name" for
+	// any realm or "Name-Realm".
+	Owner string
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// RecordAudit stores e in the audit log.
+func (g *Gringotts) RecordAudit(ctx context.Context, e *AuditEntry) error {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_log (user_id, username, guild_id, command, options, owner, outcome, message)
+		VALUES (?,?,?,?,?,?,?,?)
+		`, e.UserID, e.Username, e.GuildID, e.Command, e.Options, e.Owner, e.Outcome, e.Message,
+	)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return err
+	}
+
+	for _, o := range auditOwners(e.Owner) {
+		_, err := tx.ExecContext(ctx, `INSERT INTO audit_log_owner (audit_id, name, realm) VALUES (?,?,?)`, id, o.Name, o.Realm)
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// auditOwners splits the owner of an audit entry into bank characters. Names
+// can not contain dashes while realms can, so the first one separates them.
+func auditOwners(owner string) []Owner {
+	var owners []Owner
+	for _, s := range strings.Split(owner, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		name, realm, _ := strings.Cut(s, "-")
+		owners = append(owners, Owner{Name: name, Realm: realm})
+	}
+
+	return owners
+}
+
+// FindAuditEntries returns audit log entries of guildID matching f, newest
+// first.
+func (g *Gringotts) FindAuditEntries(ctx context.Context, guildID string, f AuditFilter) ([]*AuditEntry, error) {
+	where := []string{"guild_id = ?"}
+	args := []any{guildID}
+
+	if f.UserID != "" {
+		where = append(where, "user_id = ?")
+		args = append(args, f.UserID)
+	}
+
+	if owners := auditOwners(f.Owner); len(owners) > 0 {
+		where = append(where, "id IN (SELECT audit_id FROM audit_log_owner WHERE name = ? AND (? = '' OR realm = ?))")
+		args = append(args, owners[0].Name, owners[0].Realm, owners[0].Realm)
+	}
+
+	if !f.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.Since.UTC().Format(time.DateTime))
+	}
+
+	if !f.Until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, f.Until.UTC().Format(time.DateTime))
+	}
+
+	query := `SELECT id, created_at, user_id, username, guild_id, command, options, owner, outcome, message FROM audit_log`
+	query += " WHERE " + strings.Join(where, " AND ")
+	query += " ORDER BY id DESC"
+
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	r, err := g.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var entries []*AuditEntry
+	for r.Next() {
+		e := &AuditEntry{}
+		if err := r.Scan(&e.ID, &e.CreatedAt, &e.UserID, &e.Username, &e.GuildID, &e.Command, &e.Options, &e.Owner, &e.Outcome, &e.Message); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, r.Err()
+}
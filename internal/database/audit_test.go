@@ -0,0 +1,66 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_FindAuditEntries(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	entries := []*database.AuditEntry{
+		{UserID: "100", Username: "officer", GuildID: "1", Command: "load-inventory", Options: "{}", Owner: "Bankalt", Outcome: database.AuditOutcomeSuccess},
+		{UserID: "200", Username: "member", GuildID: "1", Command: "load-inventory", Options: "{}", Owner: "Otheralt", Outcome: database.AuditOutcomeFailure, Message: "boom"},
+		{UserID: "100", Username: "officer", GuildID: "1", Command: "load-inventory", Options: "{}", Owner: "Otheralt", Outcome: database.AuditOutcomeSuccess},
+		{UserID: "100", Username: "officer", GuildID: "1", Command: "load-inventory", Options: "{}", Owner: "Bankalt-Azjol-Nerub, Otheralt-Mankrik", Outcome: database.AuditOutcomeSuccess},
+	}
+	for _, e := range entries {
+		err := g.RecordAudit(context.Background(), e)
+		require.NoError(t, err)
+	}
+
+	_, err := db.Exec("UPDATE audit_log SET created_at = '2023-11-01 12:00:00' WHERE id = 1")
+	require.NoError(t, err)
+
+	found, err := g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 4)
+	require.Equal(t, int64(4), found[0].ID)
+
+	found, err = g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{UserID: "100"})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+
+	found, err = g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{Owner: "otheralt", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	require.Equal(t, int64(4), found[0].ID)
+	require.Equal(t, int64(3), found[1].ID)
+
+	// a realm narrows the match, realms may contain dashes
+	found, err = g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{Owner: "bankalt-azjol-nerub"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "Bankalt-Azjol-Nerub, Otheralt-Mankrik", found[0].Owner)
+
+	found, err = g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{Owner: "Otheralt-Faerlina"})
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	found, err = g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{Until: time.Date(2023, 11, 2, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, "Bankalt", found[0].Owner)
+	require.Equal(t, time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC), found[0].CreatedAt)
+
+	found, err = g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{Since: time.Date(2023, 11, 2, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	require.Equal(t, "boom", found[2].Message)
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+)
+
+// Subclass is an item category within an item class.
+type Subclass struct {
+	ClassID int
+	ID      int
+	Name    string
+}
+
+// BrowseCategory returns the items of classID held in guildID with their total
+// counts, ordered by subclass and name. A non empty subclass narrows the items
+// down to the subclass with that name.
+func (g *Gringotts) BrowseCategory(ctx context.Context, guildID string, classID int, subclass string, limit, offset int) ([]*Item, error) {
+	r, err := g.db.QueryContext(ctx, `
+		SELECT ic.item_id, COALESCE(i.name, NULLIF(ii.name, ''), ic.item_id), SUM(ic.item_count) as item_total, `+itemQuality+` FROM item_count ic
+		JOIN item_info ii
+		ON ii.item_id = ic.item_id
+		LEFT JOIN item i
+		ON i.id = ic.item_id
+		LEFT JOIN item_subclass sc
+		ON sc.class_id = ii.class_id AND sc.id = ii.subclass_id
+		WHERE ic.guild_id = ?
+		AND ii.class_id = ?
+		AND (? = '' OR sc.name = ? COLLATE NOCASE)
+		GROUP BY ic.item_id
+		HAVING item_total > 0
+		ORDER BY COALESCE(sc.name, ''), i.name
+		LIMIT ? OFFSET ?
+		`, guildID, classID, subclass, subclass, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := scanItems(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.findHolders(ctx, guildID, items); err != nil {
+		return nil, err
+	}
+
+	return items, g.EnrichItems(ctx, items)
+}
+
+// ListSubclasses returns the named subclasses of classID, ordered by name.
+func (g *Gringotts) ListSubclasses(ctx context.Context, classID int) ([]*Subclass, error) {
+	r, err := g.db.QueryContext(ctx, `
+		SELECT class_id, id, name FROM item_subclass
+		WHERE class_id = ?
+		ORDER BY name
+		`, classID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var subclasses []*Subclass
+	for r.Next() {
+		s := &Subclass{}
+		if err := r.Scan(&s.ClassID, &s.ID, &s.Name); err != nil {
+			return nil, err
+		}
+
+		subclasses = append(subclasses, s)
+	}
+
+	return subclasses, r.Err()
+}
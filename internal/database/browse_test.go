@@ -0,0 +1,58 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_BrowseCategory(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	err := g.ImportCatalog(context.Background(), []*database.ItemInfo{
+		{ID: "13463", Name: "Dreamfoil", ClassID: database.ItemClassTradeGoods, SubclassID: 9, SubclassName: "Herb", StackSize: 20},
+		{ID: "13464", Name: "Golden Sansam", ClassID: database.ItemClassTradeGoods, SubclassID: 9, SubclassName: "Herb", StackSize: 20},
+		{ID: "2589", Name: "Linen Cloth", ClassID: database.ItemClassTradeGoods, SubclassID: 5, SubclassName: "Cloth", StackSize: 20},
+		{ID: "13444", Name: "Major Mana Potion", ClassID: database.ItemClassConsumable, SubclassID: 1, SubclassName: "Potion", StackSize: 5},
+	})
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "Gbank"}, map[string]int{"13463": 30, "2589": 40, "13444": 5})
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "Gbanktwo"}, map[string]int{"13463": 10, "13464": 0})
+	require.NoError(t, err)
+
+	items, err := g.BrowseCategory(context.Background(), testGuild, database.ItemClassTradeGoods, "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "Linen Cloth", items[0].Name)
+	require.Equal(t, "Dreamfoil", items[1].Name)
+	require.Equal(t, 40, items[1].Count)
+	require.Len(t, items[1].Holders, 2)
+	require.Equal(t, "Trade Goods / Herb", items[1].Info.Category())
+
+	items, err = g.BrowseCategory(context.Background(), testGuild, database.ItemClassTradeGoods, "herb", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Dreamfoil", items[0].Name)
+
+	items, err = g.BrowseCategory(context.Background(), testGuild, database.ItemClassTradeGoods, "", 1, 1)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Dreamfoil", items[0].Name)
+
+	items, err = g.BrowseCategory(context.Background(), "2", database.ItemClassTradeGoods, "", 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	subclasses, err := g.ListSubclasses(context.Background(), database.ItemClassTradeGoods)
+	require.NoError(t, err)
+	require.Equal(t, []*database.Subclass{
+		{ClassID: database.ItemClassTradeGoods, ID: 5, Name: "Cloth"},
+		{ClassID: database.ItemClassTradeGoods, ID: 9, Name: "Herb"},
+	}, subclasses)
+}
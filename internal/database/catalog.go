@@ -0,0 +1,248 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// Item qualities, in the order the game uses.
+const (
+	QualityPoor = iota
+	QualityCommon
+	QualityUncommon
+	QualityRare
+	QualityEpic
+	QualityLegendary
+	QualityArtifact
+	QualityHeirloom
+)
+
+// qualities are the names and tooltip colors of each quality.
+var qualities = []struct {
+	name  string
+	color int
+}{
+	QualityPoor:      {name: "poor", color: 0x9d9d9d},
+	QualityCommon:    {name: "common", color: 0xffffff},
+	QualityUncommon:  {name: "uncommon", color: 0x1eff00},
+	QualityRare:      {name: "rare", color: 0x0070dd},
+	QualityEpic:      {name: "epic", color: 0xa335ee},
+	QualityLegendary: {name: "legendary", color: 0xff8000},
+	QualityArtifact:  {name: "artifact", color: 0xe6cc80},
+	QualityHeirloom:  {name: "heirloom", color: 0x00ccff},
+}
+
+// QualityName returns the lower case name of quality, common if it is unknown.
+func QualityName(quality int) string {
+	if quality < 0 || quality >= len(qualities) {
+		quality = QualityCommon
+	}
+
+	return qualities[quality].name
+}
+
+// QualityColor returns the RGB color item names of quality are shown in.
+func QualityColor(quality int) int {
+	if quality < 0 || quality >= len(qualities) {
+		quality = QualityCommon
+	}
+
+	return qualities[quality].color
+}
+
+// ParseQuality returns the quality named s, ignoring case.
+func ParseQuality(s string) (int, bool) {
+	for k, q := range qualities {
+		if strings.EqualFold(q.name, strings.TrimSpace(s)) {
+			return k, true
+		}
+	}
+
+	return 0, false
+}
+
+// Item classes, the top level item categories.
+const (
+	ItemClassConsumable    = 0
+	ItemClassContainer     = 1
+	ItemClassWeapon        = 2
+	ItemClassGem           = 3
+	ItemClassArmor         = 4
+	ItemClassReagent       = 5
+	ItemClassProjectile    = 6
+	ItemClassTradeGoods    = 7
+	ItemClassRecipe        = 9
+	ItemClassQuiver        = 11
+	ItemClassQuest         = 12
+	ItemClassKey           = 13
+	ItemClassMiscellaneous = 15
+)
+
+// Item bindings.
+const (
+	BindNone = iota
+	BindOnPickup
+	BindOnEquip
+	BindOnUse
+	BindQuest
+)
+
+// ItemInfo is the catalog metadata of an item.
+type ItemInfo struct {
+	ID      string
+	Name    string
+	Quality int
+	ClassID int
+	// ClassName and SubclassName describe the item category. When importing
+	// they are optional and update the stored category names.
+	ClassName     string
+	SubclassID    int
+	SubclassName  string
+	ItemLevel     int
+	RequiredLevel int
+	StackSize     int
+	// VendorPrice is what a vendor pays for one item, in copper.
+	VendorPrice int64
+	Binding     int
+}
+
+// Category returns the class and subclass names, such as "Weapon / Sword".
+func (i *ItemInfo) Category() string {
+	switch {
+	case i.ClassName == "":
+		return ""
+	case i.SubclassName == "" || strings.EqualFold(i.SubclassName, i.ClassName):
+		return i.ClassName
+	default:
+		return i.ClassName + " / " + i.SubclassName
+	}
+}
+
+// Stacks returns the number of stacks count of the item fills.
+func (i *ItemInfo) Stacks(count int) int {
+	if i.StackSize <= 1 {
+		return count
+	}
+
+	return (count + i.StackSize - 1) / i.StackSize
+}
+
+// ImportCatalog stores the metadata of items, replacing what was stored for
+// them before, in a single transaction. Category names given are stored as
+// well. Item names are kept as catalog metadata and do not add the items to
+// searches, which only cover items seen in uploads.
+func (g *Gringotts) ImportCatalog(ctx context.Context, items []*ItemInfo) error {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO item_info (item_id, name, quality, class_id, subclass_id, item_level, required_level, stack_size, vendor_price, binding)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+		`,
+	)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return err
+	}
+
+	defer func() { _ = stmt.Close() }() // TODO better
+
+	for _, i := range items {
+		stackSize := i.StackSize
+		if stackSize < 1 {
+			stackSize = 1
+		}
+
+		_, err := stmt.ExecContext(ctx, i.ID, i.Name, i.Quality, i.ClassID, i.SubclassID, i.ItemLevel, i.RequiredLevel, stackSize, i.VendorPrice, i.Binding)
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return err
+		}
+
+		if i.ClassName != "" {
+			_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO item_class (id, name) VALUES (?,?)`, i.ClassID, i.ClassName)
+			if err != nil {
+				_ = tx.Rollback() // TODO multierr
+				return err
+			}
+		}
+
+		if i.SubclassName != "" {
+			_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO item_subclass (class_id, id, name) VALUES (?,?,?)`, i.ClassID, i.SubclassID, i.SubclassName)
+			if err != nil {
+				_ = tx.Rollback() // TODO multierr
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetItemInfo returns the catalog metadata of the items with ids that the
+// catalog has, by ID. Names seen in uploads win over catalog names.
+func (g *Gringotts) GetItemInfo(ctx context.Context, ids ...string) (map[string]*ItemInfo, error) {
+	infos := map[string]*ItemInfo{}
+	if len(ids) == 0 {
+		return infos, nil
+	}
+
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	r, err := g.db.QueryContext(ctx, `
+		SELECT ii.item_id, COALESCE(i.name, ii.name), ii.quality, ii.class_id, COALESCE(c.name, ''), ii.subclass_id, COALESCE(sc.name, ''),
+			ii.item_level, ii.required_level, ii.stack_size, ii.vendor_price, ii.binding
+		FROM item_info ii
+		LEFT JOIN item i
+		ON i.id = ii.item_id
+		LEFT JOIN item_class c
+		ON c.id = ii.class_id
+		LEFT JOIN item_subclass sc
+		ON sc.class_id = ii.class_id AND sc.id = ii.subclass_id
+		WHERE ii.item_id IN (?`+strings.Repeat(",?", len(ids)-1)+`)
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	for r.Next() {
+		i := &ItemInfo{}
+		err := r.Scan(&i.ID, &i.Name, &i.Quality, &i.ClassID, &i.ClassName, &i.SubclassID, &i.SubclassName,
+			&i.ItemLevel, &i.RequiredLevel, &i.StackSize, &i.VendorPrice, &i.Binding)
+		if err != nil {
+			return nil, err
+		}
+
+		infos[i.ID] = i
+	}
+
+	return infos, r.Err()
+}
+
+// EnrichItems fills in the catalog metadata of items, leaving Info nil for
+// items the catalog does not have.
+func (g *Gringotts) EnrichItems(ctx context.Context, items []*Item) error {
+	ids := make([]string, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.ID)
+	}
+
+	infos, err := g.GetItemInfo(ctx, ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range items {
+		i.Info = infos[i.ID]
+	}
+
+	return nil
+}
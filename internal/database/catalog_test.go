@@ -0,0 +1,111 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_ImportCatalog(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	err := g.ImportCatalog(context.Background(), []*database.ItemInfo{
+		{ID: "2589", Name: "Linen Cloth", Quality: database.QualityCommon, ClassID: 7, SubclassID: 5, SubclassName: "Cloth", StackSize: 20, VendorPrice: 13},
+		{ID: "19019", Name: "Thunderfury", Quality: database.QualityLegendary, ClassID: 2, SubclassID: 7, SubclassName: "Sword", ItemLevel: 80, RequiredLevel: 60, Binding: database.BindOnPickup},
+	})
+	require.NoError(t, err)
+
+	infos, err := g.GetItemInfo(context.Background(), "2589", "19019", "1")
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	require.Equal(t, "Linen Cloth", infos["2589"].Name)
+	require.Equal(t, "Trade Goods / Cloth", infos["2589"].Category())
+	require.Equal(t, 3, infos["2589"].Stacks(41))
+	require.Equal(t, "Weapon / Sword", infos["19019"].Category())
+	require.Equal(t, 1, infos["19019"].StackSize)
+	require.Equal(t, database.BindOnPickup, infos["19019"].Binding)
+
+	// catalog items are not searchable until they are seen in an upload
+	result, err := g.SearchItems(context.Background(), testGuild, "thunderfury", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, result.Items)
+	require.Empty(t, result.Suggestions)
+
+	err = g.UpdateItems(context.Background(), map[string]string{"2589": "Linen Cloth"})
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "Gbank"}, map[string]int{"2589": 41})
+	require.NoError(t, err)
+
+	// search results carry the catalog metadata
+	result, err = g.SearchItems(context.Background(), testGuild, "linen", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.NotNil(t, result.Items[0].Info)
+	require.Equal(t, database.QualityCommon, result.Items[0].Info.Quality)
+
+	require.Equal(t, "legendary", database.QualityName(database.QualityLegendary))
+	require.Equal(t, 0xa335ee, database.QualityColor(database.QualityEpic))
+	q, ok := database.ParseQuality("Epic")
+	require.True(t, ok)
+	require.Equal(t, database.QualityEpic, q)
+}
+
+func TestGringotts_ImportCatalogDuplicateNames(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	// several items share a name in the game data
+	err := g.ImportCatalog(context.Background(), []*database.ItemInfo{
+		{ID: "5108", Name: "Dark Iron Fanny Pack", ClassID: 1},
+		{ID: "5109", Name: "Dark Iron Fanny Pack", ClassID: 1},
+	})
+	require.NoError(t, err)
+
+	infos, err := g.GetItemInfo(context.Background(), "5108", "5109")
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	require.Equal(t, "Dark Iron Fanny Pack", infos["5109"].Name)
+}
+
+func TestGringotts_SearchItemsByQuality(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), map[string]string{
+		"1": "Broken Sword",
+		"2": "Iron Sword",
+		"3": "Zealous Sword",
+		"4": "Ashkandi, Greatsword of the Brotherhood",
+	})
+	require.NoError(t, err)
+
+	// the catalog wins over uploaded qualities
+	err = g.UpdateItemQualities(context.Background(), map[string]int{"1": database.QualityPoor, "3": database.QualityRare, "4": database.QualityRare, "5": database.QualityEpic})
+	require.NoError(t, err)
+
+	err = g.ImportCatalog(context.Background(), []*database.ItemInfo{{ID: "4", Quality: database.QualityEpic}})
+	require.NoError(t, err)
+
+	result, err := g.SearchItems(context.Background(), testGuild, "sword", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 4)
+	require.Equal(t, "Ashkandi, Greatsword of the Brotherhood", result.Items[0].Name)
+	require.Equal(t, database.QualityEpic, result.Items[0].Quality)
+	require.Equal(t, "Zealous Sword", result.Items[1].Name)
+	require.Equal(t, "Iron Sword", result.Items[2].Name)
+	require.Equal(t, database.QualityCommon, result.Items[2].Quality)
+	require.Equal(t, "Broken Sword", result.Items[3].Name)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "sword", database.QualityRare, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+
+	// the full-text search filters the same way
+	result, err = g.SearchItems(context.Background(), testGuild, "zeal sw", database.QualityEpic, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, result.Items)
+}
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// Factions a bank character can belong to.
+const (
+	FactionAlliance = "alliance"
+	FactionHorde    = "horde"
+)
+
+// BankCharacter is a registered bank alt and the Discord users responsible for
+// it.
+type BankCharacter struct {
+	ID      int64
+	Name    string
+	Realm   string
+	Faction string
+	Flavor  string
+	UserIDs []string
+}
+
+// Owner returns the owner the character holds items as.
+func (c *BankCharacter) Owner() Owner {
+	return Owner{Name: c.Name, Realm: c.Realm, Faction: c.Faction, Flavor: c.Flavor}
+}
+
+// RegisterBankCharacter adds the bank character described by c to guildID,
+// updating its faction if it is already registered, and makes userID
+// responsible for it. An empty userID only registers the character. A
+// character created by an upload without a realm or flavor is claimed instead
+// of registering another.
+func (g *Gringotts) RegisterBankCharacter(ctx context.Context, guildID string, c *BankCharacter, userID string) error {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	id, err := resolveCharacter(ctx, tx, guildID, c.Owner())
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return err
+	}
+
+	if userID != "" {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO bank_character_user (character_id, user_id) VALUES (?,?)
+			ON CONFLICT(character_id, user_id) DO NOTHING
+			`, id, userID,
+		)
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return err
+		}
+	}
+
+	err = tx.Commit()
+
+	return err
+}
+
+// RemoveBankCharacter removes owner from the bank characters of guildID along
+// with all of its responsible users, reporting whether the character was
+// registered. A character that still holds inventory keeps its snapshots and
+// is only unregistered.
+func (g *Gringotts) RemoveBankCharacter(ctx context.Context, guildID string, owner Owner) (bool, error) {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	id, err := exactCharacter(ctx, tx, guildID, owner)
+	if err != nil || id == 0 {
+		_ = tx.Rollback() // TODO multierr
+		return false, err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM bank_character_user WHERE character_id = ?`, id)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return false, err
+	}
+
+	users, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return false, err
+	}
+
+	res, err = tx.ExecContext(ctx, `
+		DELETE FROM bank_character
+		WHERE id = ?
+		AND NOT EXISTS (SELECT 1 FROM inventory_snapshot WHERE character_id = bank_character.id)
+		`, id,
+	)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return false, err
+	}
+
+	err = tx.Commit()
+
+	return n+users > 0, err
+}
+
+// RemoveBankCharacterUser removes userID from the users responsible for the
+// bank character owner of guildID, reporting whether they were responsible for
+// it.
+func (g *Gringotts) RemoveBankCharacterUser(ctx context.Context, guildID string, owner Owner, userID string) (bool, error) {
+	id, err := exactCharacter(ctx, g.db, guildID, owner)
+	if err != nil || id == 0 {
+		return false, err
+	}
+
+	res, err := g.db.ExecContext(ctx, `DELETE FROM bank_character_user WHERE character_id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// exactCharacter returns the ID of the bank character of guildID with the
+// realm of owner, or 0 if there is none. An empty flavor matches any.
+func exactCharacter(ctx context.Context, q querier, guildID string, owner Owner) (int64, error) {
+	r, err := q.QueryContext(ctx, `
+		SELECT id FROM bank_character
+		WHERE guild_id = ? AND name = ? AND realm = ?
+		AND (? = '' OR flavor = ?)
+		`, guildID, owner.Name, owner.Realm, owner.Flavor, owner.Flavor,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var ids []int64
+	for r.Next() {
+		var id int64
+		if err := r.Scan(&id); err != nil {
+			return 0, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := r.Err(); err != nil {
+		return 0, err
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, nil
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, ErrAmbiguousOwner
+	}
+}
+
+// ListBankCharacters returns every bank character registered in guildID.
+func (g *Gringotts) ListBankCharacters(ctx context.Context, guildID string) ([]*BankCharacter, error) {
+	r, err := g.db.QueryContext(ctx, `
+		SELECT c.id, c.name, c.realm, c.faction, c.flavor, COALESCE(GROUP_CONCAT(u.user_id), '') FROM bank_character c
+		LEFT JOIN bank_character_user u
+		ON u.character_id = c.id
+		WHERE c.guild_id = ?
+		GROUP BY c.id
+		ORDER BY c.realm, c.name, c.flavor
+		`, guildID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var characters []*BankCharacter
+	for r.Next() {
+		c := &BankCharacter{}
+		var userIDs string
+		if err := r.Scan(&c.ID, &c.Name, &c.Realm, &c.Faction, &c.Flavor, &userIDs); err != nil {
+			return nil, err
+		}
+
+		if userIDs != "" {
+			c.UserIDs = strings.Split(userIDs, ",")
+		}
+
+		characters = append(characters, c)
+	}
+
+	return characters, r.Err()
+}
+
+// IsBankCharacterUser reports whether userID is responsible for a bank
+// character of guildID that owner may refer to. An empty realm or flavor
+// matches any.
+func (g *Gringotts) IsBankCharacterUser(ctx context.Context, guildID string, owner Owner, userID string) (bool, error) {
+	r := g.db.QueryRowContext(ctx, `
+		SELECT COUNT(u.id) FROM bank_character c
+		JOIN bank_character_user u
+		ON u.character_id = c.id
+		WHERE c.guild_id = ? AND c.name = ? AND u.user_id = ?
+		AND (? = '' OR c.realm = '' OR c.realm = ?)
+		AND (? = '' OR c.flavor = '' OR c.flavor = ?)
+		`, guildID, owner.Name, userID, owner.Realm, owner.Realm, owner.Flavor, owner.Flavor,
+	)
+
+	var count int
+	err := r.Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
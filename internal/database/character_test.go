@@ -0,0 +1,71 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_RegisterBankCharacter(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	gbank := &database.BankCharacter{Name: "Gbank", Realm: "Mankrik", Faction: database.FactionHorde}
+
+	err := g.RegisterBankCharacter(context.Background(), testGuild, gbank, "100")
+	require.NoError(t, err)
+
+	err = g.RegisterBankCharacter(context.Background(), testGuild, gbank, "100")
+	require.NoError(t, err)
+
+	err = g.RegisterBankCharacter(context.Background(), testGuild, gbank, "200")
+	require.NoError(t, err)
+
+	err = g.RegisterBankCharacter(context.Background(), testGuild, &database.BankCharacter{Name: "gbank", Realm: "Pagle", Faction: database.FactionAlliance}, "")
+	require.NoError(t, err)
+
+	characters, err := g.ListBankCharacters(context.Background(), testGuild)
+	require.NoError(t, err)
+	require.Len(t, characters, 2)
+	require.Equal(t, "Gbank", characters[0].Name)
+	require.Equal(t, "Mankrik", characters[0].Realm)
+	require.Equal(t, database.FactionHorde, characters[0].Faction)
+	require.ElementsMatch(t, []string{"100", "200"}, characters[0].UserIDs)
+	require.Equal(t, "Pagle", characters[1].Realm)
+	require.Empty(t, characters[1].UserIDs)
+
+	ok, err := g.IsBankCharacterUser(context.Background(), testGuild, database.Owner{Name: "GBANK"}, "200")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = g.IsBankCharacterUser(context.Background(), testGuild, database.Owner{Name: "Gbank"}, "300")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	removed, err := g.RemoveBankCharacterUser(context.Background(), testGuild, database.Owner{Name: "Gbank", Realm: "Mankrik"}, "200")
+	require.NoError(t, err)
+	require.True(t, removed)
+
+	ok, err = g.IsBankCharacterUser(context.Background(), testGuild, database.Owner{Name: "Gbank"}, "200")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	removed, err = g.RemoveBankCharacter(context.Background(), testGuild, database.Owner{Name: "Gbank", Realm: "Mankrik"})
+	require.NoError(t, err)
+	require.True(t, removed)
+
+	removed, err = g.RemoveBankCharacter(context.Background(), testGuild, database.Owner{Name: "Gbank", Realm: "Mankrik"})
+	require.NoError(t, err)
+	require.False(t, removed)
+
+	ok, err = g.IsBankCharacterUser(context.Background(), testGuild, database.Owner{Name: "Gbank"}, "100")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	characters, err = g.ListBankCharacters(context.Background(), testGuild)
+	require.NoError(t, err)
+	require.Len(t, characters, 1)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"strings"
 )
 
 type Gringotts struct {
@@ -19,47 +19,217 @@ type Item struct {
 	ID    string
 	Name  string
 	Count int
+	// Quality is from the catalog, or from uploads for items the catalog
+	// does not have, common if neither knows it.
+	Quality int
+	// Holders are the owners currently holding the item, ordered by realm,
+	// faction and name.
+	Holders []*Holder
+	// Info is the catalog metadata of the item, nil if the catalog does not
+	// have it.
+	Info *ItemInfo
 }
 
-func (g *Gringotts) FindItem(ctx context.Context, searchString string) ([]*Item, error) {
-	query := fmt.Sprintf(`
-		SELECT i.id, i.name, SUM(ic.item_count) as item_total FROM item i
+// Holder is the total count of an item held by one owner.
+type Holder struct {
+	Owner Owner
+	Count int
+}
+
+// Locations an item can be held in on a bank character. Counts uploaded
+// without any location breakdown are stored under LocationUnknown.
+const (
+	LocationUnknown     = "unknown"
+	LocationBags        = "bags"
+	LocationBank        = "bank"
+	LocationReagentBank = "reagentBank"
+	LocationMail        = "mail"
+	LocationEquipped    = "equipped"
+)
+
+// ItemLocation is the count of an item held by a single owner in a single
+// location.
+type ItemLocation struct {
+	ID       string
+	Name     string
+	Owner    Owner
+	Location string
+	Count    int
+}
+
+// itemQuality is the quality of item i with item_info ii left joined.
+const itemQuality = `COALESCE(ii.quality, i.quality, 1)`
+
+// FindItem finds items whose name contains searchString, returning at most
+// limit results after skipping offset. Exact name matches are ranked first, then
+// prefix matches, then any other substring matches. Counts and holders only
+// include the inventories of guildID, item names are shared by every guild.
+func (g *Gringotts) FindItem(ctx context.Context, guildID, searchString string, limit, offset int) ([]*Item, error) {
+	return g.findItems(ctx, guildID, searchString, QualityPoor, limit, offset)
+}
+
+// findItems is FindItem leaving out items below minQuality. Within each rank
+// the best quality comes first.
+func (g *Gringotts) findItems(ctx context.Context, guildID, searchString string, minQuality, limit, offset int) ([]*Item, error) {
+	query := `
+		SELECT i.id, i.name, COALESCE(SUM(ic.item_count), 0) as item_total, ` + itemQuality + ` FROM item i
+		LEFT JOIN item_info ii
+		ON ii.item_id = i.id
 		LEFT JOIN item_count ic
 		ON i.id = ic.item_id
-		WHERE i.name LIKE '%%%s%%'
-		GROUP BY i.name
-		`, searchString,
-	)
+		AND ic.guild_id = ?
+		WHERE i.name LIKE '%' || ? || '%' ESCAPE '\'
+		AND ` + itemQuality + ` >= ?
+		GROUP BY i.id
+		ORDER BY CASE
+			WHEN i.name = ? THEN 0
+			WHEN i.name LIKE ? || '%' ESCAPE '\' THEN 1
+			ELSE 2
+		END, ` + itemQuality + ` DESC, i.name
+		LIMIT ? OFFSET ?
+		`
+
+	escaped := escapeLike(searchString)
+
+	r, err := g.db.QueryContext(ctx, query, guildID, escaped, minQuality, searchString, escaped, limit, offset)
+	if err != nil {
+		return nil, err
+	}
 
-	r, err := g.db.QueryContext(ctx, query)
+	items, err := scanItems(r)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := g.findHolders(ctx, guildID, items); err != nil {
+		return nil, err
+	}
+
+	return items, g.EnrichItems(ctx, items)
+}
+
+// scanItems reads items with their total count and quality.
+func scanItems(r *sql.Rows) ([]*Item, error) {
 	defer func() { _ = r.Close() }()
 
 	var items []*Item
 	for r.Next() {
 		i := &Item{}
-		if err := r.Scan(&i.ID, &i.Name, &i.Count); err != nil {
+		if err := r.Scan(&i.ID, &i.Name, &i.Count, &i.Quality); err != nil {
 			return nil, err
 		}
 
 		items = append(items, i)
 	}
 
-	return items, nil
+	return items, r.Err()
+}
+
+// findHolders fills in the holders of items in guildID.
+func (g *Gringotts) findHolders(ctx context.Context, guildID string, items []*Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	byID := map[string]*Item{}
+	args := []any{guildID}
+	for _, i := range items {
+		byID[strings.ToLower(i.ID)] = i
+		args = append(args, i.ID)
+	}
+
+	r, err := g.db.QueryContext(ctx, `
+		SELECT item_id, owner, realm, faction, flavor, SUM(item_count) FROM item_count
+		WHERE guild_id = ?
+		AND item_id IN (?`+strings.Repeat(",?", len(items)-1)+`)
+		GROUP BY item_id, character_id
+		ORDER BY realm, faction, owner
+		`, args...,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	for r.Next() {
+		var id string
+		h := &Holder{}
+		if err := r.Scan(&id, &h.Owner.Name, &h.Owner.Realm, &h.Owner.Faction, &h.Owner.Flavor, &h.Count); err != nil {
+			return err
+		}
+
+		if i, ok := byID[strings.ToLower(id)]; ok {
+			i.Holders = append(i.Holders, h)
+		}
+	}
+
+	return r.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s so it can be matched literally
+// using ESCAPE '\'.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// FindItemLocations finds items matching searchString held in guildID and
+// returns their counts grouped by owner and location. An empty location
+// matches every location.
+func (g *Gringotts) FindItemLocations(ctx context.Context, guildID, searchString string, location string) ([]*ItemLocation, error) {
+	r, err := g.db.QueryContext(ctx, `
+		SELECT i.id, i.name, ic.owner, ic.realm, ic.faction, ic.flavor, ic.location, SUM(ic.item_count) as item_total FROM item i
+		JOIN item_count ic
+		ON i.id = ic.item_id
+		WHERE ic.guild_id = ?
+		AND i.name LIKE '%' || ? || '%' ESCAPE '\'
+		AND (? = '' OR ic.location = ?)
+		GROUP BY i.id, ic.character_id, ic.location
+		ORDER BY i.name, ic.realm, ic.faction, ic.owner, ic.location
+		`, guildID, escapeLike(searchString), location, location,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var locations []*ItemLocation
+	for r.Next() {
+		l := &ItemLocation{}
+		if err := r.Scan(&l.ID, &l.Name, &l.Owner.Name, &l.Owner.Realm, &l.Owner.Faction, &l.Owner.Flavor, &l.Location, &l.Count); err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, r.Err()
 }
 
-func (g *Gringotts) GetItemCount(ctx context.Context, owner string, itemID int) (int, error) {
-	stmt, err := g.db.PrepareContext(ctx, `SELECT item_count FROM item_count WHERE owner = ? AND item_id = ?`)
+func (g *Gringotts) GetItemCount(ctx context.Context, guildID string, owner Owner, itemID int) (int, error) {
+	ids, err := matchCharacters(ctx, g.db, guildID, owner)
+	if err != nil {
+		return -1, err
+	}
+
+	switch {
+	case len(ids) == 0:
+		return -1, sql.ErrNoRows
+	case len(ids) > 1:
+		return -1, ErrAmbiguousOwner
+	}
+
+	stmt, err := g.db.PrepareContext(ctx, `SELECT SUM(item_count) FROM item_count WHERE character_id = ? AND item_id = ? GROUP BY character_id, item_id`)
 	if err != nil {
 		return -1, err
 	}
 
 	defer func() { _ = stmt.Close() }() // TODO better
 
-	r := stmt.QueryRowContext(ctx, owner, itemID)
+	r := stmt.QueryRowContext(ctx, ids[0], itemID)
 
 	var count int
 	err = r.Scan(&count)
@@ -95,36 +265,55 @@ func (g *Gringotts) GetItemName(ctx context.Context, id string) (string, error)
 	return name, nil
 }
 
-func (g *Gringotts) UpdateItemCounts(ctx context.Context, owner string, itemCounts map[string]int) error {
+// UpdateItemCounts records itemCounts as the current inventory of owner in
+// guildID. The counts are stored without a location breakdown.
+func (g *Gringotts) UpdateItemCounts(ctx context.Context, guildID string, owner Owner, itemCounts map[string]int) error {
+	return g.UpdateItemLocations(ctx, guildID, owner, map[string]map[string]int{LocationUnknown: itemCounts})
+}
+
+// UpdateItemLocations records a new inventory snapshot for owner in guildID
+// from locations, which maps a location to the item counts held there. The new
+// snapshot becomes the owner's current inventory. The owner's bank character is
+// created if it does not exist yet.
+func (g *Gringotts) UpdateItemLocations(ctx context.Context, guildID string, owner Owner, locations map[string]map[string]int) error {
 	tx, err := g.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt1, err := tx.PrepareContext(ctx, `DELETE FROM item_count where owner = ?`)
+	characterID, err := resolveCharacter(ctx, tx, guildID, owner)
 	if err != nil {
+		_ = tx.Rollback() // TODO multierr
 		return err
 	}
 
-	_, err = stmt1.ExecContext(ctx, owner)
+	res, err := tx.ExecContext(ctx, `INSERT INTO inventory_snapshot (guild_id, owner, character_id) VALUES (?,?,?)`, guildID, owner.Name, characterID)
 	if err != nil {
+		_ = tx.Rollback() // TODO multierr
 		return err
 	}
 
-	defer func() { _ = stmt1.Close() }() // TODO better
+	snapshotID, err := res.LastInsertId()
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return err
+	}
 
-	stmt2, err := tx.PrepareContext(ctx, `INSERT INTO item_count (owner, item_id, item_count) VALUES (?,?,?)`)
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO inventory_snapshot_item (snapshot_id, item_id, location, item_count) VALUES (?,?,?,?)`)
 	if err != nil {
+		_ = tx.Rollback() // TODO multierr
 		return err
 	}
 
-	defer func() { _ = stmt2.Close() }() // TODO better
+	defer func() { _ = stmt.Close() }() // TODO better
 
-	for k, v := range itemCounts {
-		_, err := stmt2.ExecContext(ctx, owner, k, v)
-		if err != nil {
-			_ = tx.Rollback() // TODO multierr
-			return err
+	for location, itemCounts := range locations {
+		for k, v := range itemCounts {
+			_, err := stmt.ExecContext(ctx, snapshotID, k, location, v)
+			if err != nil {
+				_ = tx.Rollback() // TODO multierr
+				return err
+			}
 		}
 	}
 
@@ -141,13 +330,42 @@ func (g *Gringotts) UpdateItems(ctx context.Context, items map[string]string) er
 
 	stmt, err := tx.PrepareContext(ctx, `INSERT INTO item (id, name) values(?, ?) ON CONFLICT(id) DO UPDATE SET name = excluded.name WHERE id = excluded.id`)
 	if err != nil {
+		_ = tx.Rollback() // TODO multierr
 		return err
 	}
 
 	defer func() { _ = stmt.Close() }() // TODO better
 
+	ftsDelete, err := tx.PrepareContext(ctx, `DELETE FROM item_fts WHERE item_id = ?`)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return err
+	}
+
+	defer func() { _ = ftsDelete.Close() }() // TODO better
+
+	ftsInsert, err := tx.PrepareContext(ctx, `INSERT INTO item_fts (name, item_id) SELECT name, id FROM item WHERE id = ?`)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return err
+	}
+
+	defer func() { _ = ftsInsert.Close() }() // TODO better
+
 	for k, v := range items {
-		_, err := stmt.ExecContext(ctx, k, v)
+		_, err := ftsDelete.ExecContext(ctx, k)
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx, k, v)
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return err
+		}
+
+		_, err = ftsInsert.ExecContext(ctx, k)
 		if err != nil {
 			_ = tx.Rollback() // TODO multierr
 			return err
@@ -158,3 +376,22 @@ func (g *Gringotts) UpdateItems(ctx context.Context, items map[string]string) er
 
 	return err
 }
+
+// UpdateItemQualities records the quality uploads report for items, keyed by
+// item ID. Items not stored yet are skipped, UpdateItems stores them.
+func (g *Gringotts) UpdateItemQualities(ctx context.Context, qualities map[string]int) error {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for id, quality := range qualities {
+		_, err := tx.ExecContext(ctx, `UPDATE item SET quality = ? WHERE id = ?`, quality, id)
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
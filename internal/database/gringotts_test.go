@@ -21,6 +21,9 @@ func getGringotts(t *testing.T) (*database.Gringotts, *sql.DB) {
 	return database.NewGringotts(db), db
 }
 
+// testGuild is the guild the test data is stored in.
+const testGuild = "1"
+
 var (
 	items1 = map[string]string{
 		"1": "item 1",
@@ -69,12 +72,12 @@ func TestGringotts_UpdateItemCounts(t *testing.T) {
 
 	defer func() { _ = db.Close() }()
 
-	testOwner := "testChar"
+	testOwner := database.Owner{Name: "testChar"}
 
-	err := g.UpdateItemCounts(context.Background(), testOwner, itemCounts1)
+	err := g.UpdateItemCounts(context.Background(), testGuild, testOwner, itemCounts1)
 	require.NoError(t, err)
 
-	r, err := db.Query(fmt.Sprintf("SELECT COUNT(id) FROM item_count WHERE owner='%s'", testOwner))
+	r, err := db.Query(fmt.Sprintf("SELECT COUNT(id) FROM item_count WHERE owner='%s'", testOwner.Name))
 	require.NoError(t, err)
 
 	for r.Next() {
@@ -86,30 +89,30 @@ func TestGringotts_UpdateItemCounts(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
-	one, err := g.GetItemCount(context.Background(), testOwner, 1)
+	one, err := g.GetItemCount(context.Background(), testGuild, testOwner, 1)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts1["1"], one)
 
-	two, err := g.GetItemCount(context.Background(), testOwner, 2)
+	two, err := g.GetItemCount(context.Background(), testGuild, testOwner, 2)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts1["2"], two)
 
-	three, err := g.GetItemCount(context.Background(), testOwner, 3)
+	three, err := g.GetItemCount(context.Background(), testGuild, testOwner, 3)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts1["3"], three)
 
-	four, err := g.GetItemCount(context.Background(), testOwner, 4)
+	four, err := g.GetItemCount(context.Background(), testGuild, testOwner, 4)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts1["4"], four)
 
-	five, err := g.GetItemCount(context.Background(), testOwner, 5)
+	five, err := g.GetItemCount(context.Background(), testGuild, testOwner, 5)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts1["5"], five)
 
-	err = g.UpdateItemCounts(context.Background(), testOwner, itemCounts2)
+	err = g.UpdateItemCounts(context.Background(), testGuild, testOwner, itemCounts2)
 	require.NoError(t, err)
 
-	r, err = db.Query(fmt.Sprintf("SELECT COUNT(id) FROM item_count WHERE owner='%s'", testOwner))
+	r, err = db.Query(fmt.Sprintf("SELECT COUNT(id) FROM item_count WHERE owner='%s'", testOwner.Name))
 	require.NoError(t, err)
 
 	for r.Next() {
@@ -122,23 +125,23 @@ func TestGringotts_UpdateItemCounts(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
-	one, err = g.GetItemCount(context.Background(), testOwner, 1)
+	one, err = g.GetItemCount(context.Background(), testGuild, testOwner, 1)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts2["1"], one)
 
-	two, err = g.GetItemCount(context.Background(), testOwner, 2)
+	two, err = g.GetItemCount(context.Background(), testGuild, testOwner, 2)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts2["2"], two)
 
-	three, err = g.GetItemCount(context.Background(), testOwner, 3)
+	three, err = g.GetItemCount(context.Background(), testGuild, testOwner, 3)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts2["3"], three)
 
-	four, err = g.GetItemCount(context.Background(), testOwner, 4)
+	four, err = g.GetItemCount(context.Background(), testGuild, testOwner, 4)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts2["4"], four)
 
-	five, err = g.GetItemCount(context.Background(), testOwner, 5)
+	five, err = g.GetItemCount(context.Background(), testGuild, testOwner, 5)
 	require.NoError(t, err)
 	require.Equal(t, itemCounts2["5"], five)
 }
@@ -195,3 +198,176 @@ func TestGringotts_UpdateItems(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, items2["5"], five)
 }
+
+func TestGringotts_FindItemLocations(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), items1)
+	require.NoError(t, err)
+
+	err = g.UpdateItemLocations(context.Background(), testGuild, database.Owner{Name: "alt1"}, map[string]map[string]int{
+		database.LocationBags: {"1": 1, "2": 2},
+		database.LocationBank: {"1": 10},
+	})
+	require.NoError(t, err)
+
+	err = g.UpdateItemLocations(context.Background(), testGuild, database.Owner{Name: "alt2"}, map[string]map[string]int{
+		database.LocationMail: {"1": 5},
+	})
+	require.NoError(t, err)
+
+	locations, err := g.FindItemLocations(context.Background(), testGuild, "item 1", "")
+	require.NoError(t, err)
+	require.Len(t, locations, 3)
+	require.Equal(t, "alt1", locations[0].Owner.Name)
+	require.Equal(t, database.LocationBags, locations[0].Location)
+	require.Equal(t, 1, locations[0].Count)
+	require.Equal(t, "alt1", locations[1].Owner.Name)
+	require.Equal(t, database.LocationBank, locations[1].Location)
+	require.Equal(t, 10, locations[1].Count)
+	require.Equal(t, "alt2", locations[2].Owner.Name)
+	require.Equal(t, database.LocationMail, locations[2].Location)
+	require.Equal(t, 5, locations[2].Count)
+
+	locations, err = g.FindItemLocations(context.Background(), testGuild, "item", database.LocationBags)
+	require.NoError(t, err)
+	require.Len(t, locations, 2)
+	require.Equal(t, "1", locations[0].ID)
+	require.Equal(t, "2", locations[1].ID)
+
+	total, err := g.GetItemCount(context.Background(), testGuild, database.Owner{Name: "alt1"}, 1)
+	require.NoError(t, err)
+	require.Equal(t, 11, total)
+}
+
+func TestGringotts_FindItem(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), map[string]string{
+		"1": "Elixir of Greater Agility",
+		"2": "Greater Healing Potion",
+		"3": "Greater Mana Potion",
+		"4": "Elixir of the Mongoose",
+		"5": "Dwarf's 100% Ale",
+		"6": "Dwarf's 100 Proof Ale",
+		"7": "Greater",
+	})
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "testChar"}, map[string]int{"2": 5, "3": 2})
+	require.NoError(t, err)
+
+	items, err := g.FindItem(context.Background(), testGuild, "greater", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	require.Equal(t, "Greater", items[0].Name)
+	require.Equal(t, 0, items[0].Count)
+	require.Equal(t, "Greater Healing Potion", items[1].Name)
+	require.Equal(t, 5, items[1].Count)
+	require.Equal(t, "Greater Mana Potion", items[2].Name)
+	require.Equal(t, 2, items[2].Count)
+	require.Equal(t, "Elixir of Greater Agility", items[3].Name)
+
+	require.Equal(t, []*database.Holder{{Owner: database.Owner{Name: "testChar"}, Count: 5}}, items[1].Holders)
+	require.Empty(t, items[0].Holders)
+
+	items, err = g.FindItem(context.Background(), testGuild, "greater", 2, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	items, err = g.FindItem(context.Background(), testGuild, "greater", 2, 2)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "Greater Mana Potion", items[0].Name)
+
+	items, err = g.FindItem(context.Background(), testGuild, "100%", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "Dwarf's 100% Ale", items[0].Name)
+
+	items, err = g.FindItem(context.Background(), testGuild, "Dwarf's", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	items, err = g.FindItem(context.Background(), testGuild, "'; DROP TABLE item; --", 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	name, err := g.GetItemName(context.Background(), "1")
+	require.NoError(t, err)
+	require.Equal(t, "Elixir of Greater Agility", name)
+}
+
+func TestGringotts_SearchItems(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), map[string]string{
+		"12359": "Thorium Bar",
+		"12360": "Arcanite Bar",
+		"12363": "Arcane Crystal",
+		"13444": "Major Mana Potion",
+	})
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "testChar"}, map[string]int{"12360": 4})
+	require.NoError(t, err)
+
+	result, err := g.SearchItems(context.Background(), testGuild, "arcanite", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, "Arcanite Bar", result.Items[0].Name)
+	require.Equal(t, 4, result.Items[0].Count)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "arcanit bar", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, "Arcanite Bar", result.Items[0].Name)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "bar arcan", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, "12360", result.Items[0].ID)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "bar", database.QualityPoor, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, "Thorium Bar", result.Items[0].Name)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "bar", database.QualityPoor, 1, 2)
+	require.NoError(t, err)
+	require.Empty(t, result.Items)
+	require.Empty(t, result.Suggestions)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "arcanitr bar", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, result.Items)
+	require.Equal(t, []string{"Arcanite Bar"}, result.Suggestions)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "major mama potoin", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, result.Items)
+	require.Equal(t, []string{"Major Mana Potion"}, result.Suggestions)
+
+	result, err = g.SearchItems(context.Background(), testGuild, `"*`, database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, result.Items)
+	require.Empty(t, result.Suggestions)
+
+	err = g.UpdateItems(context.Background(), map[string]string{"12360": "Arcanite Ingot"})
+	require.NoError(t, err)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "arcan ingot", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, "Arcanite Ingot", result.Items[0].Name)
+
+	result, err = g.SearchItems(context.Background(), testGuild, "arcan bar", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Empty(t, result.Items)
+}
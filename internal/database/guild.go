@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+)
+
+// AssignGuild assigns the snapshots, role capabilities and bank characters
+// stored before data was kept per guild to guildID, returning the number of
+// rows assigned. Rows that would duplicate ones already in guildID are left
+// unassigned.
+func (g *Gringotts) AssignGuild(ctx context.Context, guildID string) (int64, error) {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var assigned int64
+	for _, q := range []string{
+		`UPDATE inventory_snapshot SET guild_id = ? WHERE guild_id = ''`,
+		`UPDATE OR IGNORE role_capability SET guild_id = ? WHERE guild_id = ''`,
+		`UPDATE OR IGNORE bank_character SET guild_id = ? WHERE guild_id = ''`,
+		`UPDATE audit_log SET guild_id = ? WHERE guild_id = ''`,
+	} {
+		res, err := tx.ExecContext(ctx, q, guildID)
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return 0, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback() // TODO multierr
+			return 0, err
+		}
+
+		assigned += n
+	}
+
+	err = tx.Commit()
+
+	return assigned, err
+}
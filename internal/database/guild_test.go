@@ -0,0 +1,96 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_GuildIsolation(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	const otherGuild = "2"
+
+	err := g.UpdateItems(context.Background(), items1)
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "Gbank"}, map[string]int{"1": 1})
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), otherGuild, database.Owner{Name: "Gbank"}, map[string]int{"1": 10, "2": 20})
+	require.NoError(t, err)
+
+	items, err := g.FindItem(context.Background(), testGuild, "item 1", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, 1, items[0].Count)
+
+	items, err = g.FindItem(context.Background(), otherGuild, "item 1", 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 10, items[0].Count)
+
+	locations, err := g.FindItemLocations(context.Background(), testGuild, "item", "")
+	require.NoError(t, err)
+	require.Len(t, locations, 1)
+
+	snapshots, err := g.ListSnapshots(context.Background(), testGuild, database.Owner{Name: "Gbank"}, 10)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	// snapshots of another guild can not be read by ID
+	other, err := g.ListSnapshots(context.Background(), otherGuild, database.Owner{Name: "Gbank"}, 10)
+	require.NoError(t, err)
+
+	found, err := g.GetSnapshotItems(context.Background(), testGuild, other[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, found)
+
+	diffs, err := g.DiffSnapshots(context.Background(), testGuild, 0, other[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+
+	err = g.RegisterBankCharacter(context.Background(), testGuild, &database.BankCharacter{Name: "Gbank", Realm: "Mankrik", Faction: database.FactionAlliance}, "100")
+	require.NoError(t, err)
+
+	ok, err := g.IsBankCharacterUser(context.Background(), otherGuild, database.Owner{Name: "Gbank"}, "100")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	err = g.GrantCapability(context.Background(), testGuild, "10", database.CapabilityAdmin)
+	require.NoError(t, err)
+
+	capabilities, err := g.GetCapabilities(context.Background(), otherGuild, []string{"10"})
+	require.NoError(t, err)
+	require.Empty(t, capabilities)
+}
+
+func TestGringotts_AssignGuild(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItemCounts(context.Background(), "", database.Owner{Name: "Gbank"}, map[string]int{"1": 1})
+	require.NoError(t, err)
+
+	err = g.GrantCapability(context.Background(), "", "10", database.CapabilityAdmin)
+	require.NoError(t, err)
+
+	err = g.GrantCapability(context.Background(), testGuild, "10", database.CapabilityAdmin)
+	require.NoError(t, err)
+
+	// the snapshot and its bank character move, the capability already
+	// granted in testGuild is left behind
+	assigned, err := g.AssignGuild(context.Background(), testGuild)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), assigned)
+
+	snapshots, err := g.ListSnapshots(context.Background(), testGuild, database.Owner{Name: "Gbank"}, 10)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	assigned, err = g.AssignGuild(context.Background(), testGuild)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), assigned)
+}
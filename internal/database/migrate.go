@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"sort"
+	"time"
 )
 
 var Migrations = map[int][]string{
@@ -39,6 +40,449 @@ var Migrations = map[int][]string{
 		INSERT INTO migration (migration_id) values(2)
 		`,
 	},
+	3: {
+		`
+		CREATE TABLE IF NOT EXISTS item_count_location (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    owner VARCHAR(64) NOT NULL,
+		    item_id VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    location VARCHAR(32) NOT NULL DEFAULT 'unknown',
+		    item_count INTEGER NOT NULL DEFAULT 0,
+		    FOREIGN KEY(item_id) REFERENCES item(id),
+		    UNIQUE(owner, item_id, location)
+		)
+		`,
+		`
+		INSERT INTO item_count_location (owner, item_id, location, item_count)
+		SELECT owner, item_id, 'unknown', item_count FROM item_count
+		`,
+		`
+		DROP TABLE item_count
+		`,
+		`
+		ALTER TABLE item_count_location RENAME TO item_count
+		`,
+		`
+		INSERT INTO migration (migration_id) values(3)
+		`,
+	},
+	4: {
+		// fts5 is only available in go-sqlite3 behind the sqlite_fts5 build
+		// tag, fts4 is compiled in by default and supports the same token and
+		// prefix queries. docid is the rowid of the matching item row.
+		`
+		CREATE VIRTUAL TABLE IF NOT EXISTS item_fts USING fts4(name, tokenize=unicode61)
+		`,
+		`
+		INSERT INTO item_fts (docid, name) SELECT rowid, name FROM item
+		`,
+		`
+		INSERT INTO migration (migration_id) values(4)
+		`,
+	},
+	5: {
+		`
+		CREATE TABLE IF NOT EXISTS inventory_snapshot (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    owner VARCHAR(64) NOT NULL,
+		    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS inventory_snapshot_owner ON inventory_snapshot (owner, id)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS inventory_snapshot_item (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    snapshot_id INTEGER NOT NULL,
+		    item_id VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    location VARCHAR(32) NOT NULL DEFAULT 'unknown',
+		    item_count INTEGER NOT NULL DEFAULT 0,
+		    FOREIGN KEY(snapshot_id) REFERENCES inventory_snapshot(id),
+		    FOREIGN KEY(item_id) REFERENCES item(id),
+		    UNIQUE(snapshot_id, item_id, location)
+		)
+		`,
+		`
+		INSERT INTO inventory_snapshot (owner) SELECT DISTINCT owner FROM item_count
+		`,
+		`
+		INSERT INTO inventory_snapshot_item (snapshot_id, item_id, location, item_count)
+		SELECT s.id, ic.item_id, ic.location, ic.item_count FROM item_count ic
+		JOIN inventory_snapshot s
+		ON s.owner = ic.owner
+		`,
+		`
+		DROP TABLE item_count
+		`,
+		// item_count is the current inventory, the latest snapshot per owner.
+		`
+		CREATE VIEW IF NOT EXISTS item_count AS
+		SELECT si.id, s.owner, si.item_id, si.location, si.item_count FROM inventory_snapshot_item si
+		JOIN inventory_snapshot s
+		ON s.id = si.snapshot_id
+		WHERE s.id = (SELECT MAX(id) FROM inventory_snapshot WHERE owner = s.owner)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(5)
+		`,
+	},
+	6: {
+		`
+		CREATE TABLE IF NOT EXISTS audit_log (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		    user_id VARCHAR(32) NOT NULL,
+		    username VARCHAR(64) NOT NULL,
+		    guild_id VARCHAR(32) NOT NULL,
+		    command VARCHAR(64) NOT NULL,
+		    options TEXT NOT NULL,
+		    owner VARCHAR(64) NOT NULL DEFAULT '',
+		    outcome VARCHAR(16) NOT NULL,
+		    message TEXT NOT NULL DEFAULT ''
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS audit_log_created_at ON audit_log (created_at)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(6)
+		`,
+	},
+	7: {
+		`
+		CREATE TABLE IF NOT EXISTS role_capability (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    role_id VARCHAR(32) NOT NULL,
+		    capability VARCHAR(16) NOT NULL,
+		    UNIQUE(role_id, capability)
+		)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(7)
+		`,
+	},
+	8: {
+		`
+		CREATE TABLE IF NOT EXISTS bank_character (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    name VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    realm VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    faction VARCHAR(16) NOT NULL,
+		    UNIQUE(name, realm)
+		)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS bank_character_user (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    character_id INTEGER NOT NULL,
+		    user_id VARCHAR(32) NOT NULL,
+		    FOREIGN KEY(character_id) REFERENCES bank_character(id),
+		    UNIQUE(character_id, user_id)
+		)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(8)
+		`,
+	},
+	9: {
+		// rows from before guilds were tracked get an empty guild_id until
+		// they are assigned to a guild with AssignGuild.
+		`
+		ALTER TABLE inventory_snapshot ADD COLUMN guild_id VARCHAR(32) NOT NULL DEFAULT ''
+		`,
+		`
+		DROP INDEX IF EXISTS inventory_snapshot_owner
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS inventory_snapshot_guild_owner ON inventory_snapshot (guild_id, owner, id)
+		`,
+		`
+		DROP VIEW item_count
+		`,
+		`
+		CREATE VIEW IF NOT EXISTS item_count AS
+		SELECT si.id, s.guild_id, s.owner, si.item_id, si.location, si.item_count FROM inventory_snapshot_item si
+		JOIN inventory_snapshot s
+		ON s.id = si.snapshot_id
+		WHERE s.id = (SELECT MAX(id) FROM inventory_snapshot WHERE guild_id = s.guild_id AND owner = s.owner)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS role_capability_guild (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    guild_id VARCHAR(32) NOT NULL,
+		    role_id VARCHAR(32) NOT NULL,
+		    capability VARCHAR(16) NOT NULL,
+		    UNIQUE(guild_id, role_id, capability)
+		)
+		`,
+		`
+		INSERT INTO role_capability_guild (id, guild_id, role_id, capability)
+		SELECT id, '', role_id, capability FROM role_capability
+		`,
+		`
+		DROP TABLE role_capability
+		`,
+		`
+		ALTER TABLE role_capability_guild RENAME TO role_capability
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS bank_character_guild (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    guild_id VARCHAR(32) NOT NULL,
+		    name VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    realm VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    faction VARCHAR(16) NOT NULL,
+		    UNIQUE(guild_id, name, realm)
+		)
+		`,
+		`
+		INSERT INTO bank_character_guild (id, guild_id, name, realm, faction)
+		SELECT id, '', name, realm, faction FROM bank_character
+		`,
+		`
+		DROP TABLE bank_character
+		`,
+		`
+		ALTER TABLE bank_character_guild RENAME TO bank_character
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS audit_log_guild_created_at ON audit_log (guild_id, created_at)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(9)
+		`,
+	},
+	10: {
+		// bank characters become the owners of inventory snapshots, unique
+		// by name, realm and game flavor.
+		`
+		CREATE TABLE IF NOT EXISTS bank_character_flavor (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    guild_id VARCHAR(32) NOT NULL,
+		    name VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    realm VARCHAR(64) NOT NULL DEFAULT '' COLLATE NOCASE,
+		    faction VARCHAR(16) NOT NULL DEFAULT '',
+		    flavor VARCHAR(16) NOT NULL DEFAULT '',
+		    UNIQUE(guild_id, name, realm, flavor)
+		)
+		`,
+		`
+		INSERT INTO bank_character_flavor (id, guild_id, name, realm, faction)
+		SELECT id, guild_id, name, realm, faction FROM bank_character
+		`,
+		`
+		DROP TABLE bank_character
+		`,
+		`
+		ALTER TABLE bank_character_flavor RENAME TO bank_character
+		`,
+		// owners that were never registered get a character with an unknown
+		// realm, filled in by their next upload or registration.
+		`
+		INSERT INTO bank_character (guild_id, name)
+		SELECT DISTINCT s.guild_id, s.owner FROM inventory_snapshot s
+		WHERE NOT EXISTS (SELECT 1 FROM bank_character c WHERE c.guild_id = s.guild_id AND c.name = s.owner)
+		`,
+		`
+		ALTER TABLE inventory_snapshot ADD COLUMN character_id INTEGER REFERENCES bank_character(id)
+		`,
+		`
+		UPDATE inventory_snapshot SET character_id = (
+			SELECT MIN(c.id) FROM bank_character c
+			WHERE c.guild_id = inventory_snapshot.guild_id AND c.name = inventory_snapshot.owner
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS inventory_snapshot_character ON inventory_snapshot (character_id, id)
+		`,
+		`
+		DROP VIEW item_count
+		`,
+		`
+		CREATE VIEW IF NOT EXISTS item_count AS
+		SELECT si.id, s.guild_id, s.character_id, c.name AS owner, c.realm, c.faction, c.flavor, si.item_id, si.location, si.item_count FROM inventory_snapshot_item si
+		JOIN inventory_snapshot s
+		ON s.id = si.snapshot_id
+		JOIN bank_character c
+		ON c.id = s.character_id
+		WHERE s.id = (SELECT MAX(id) FROM inventory_snapshot WHERE character_id = s.character_id)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(10)
+		`,
+	},
+	11: {
+		// item metadata loaded from a catalog dump, items the catalog does
+		// not know have no row.
+		`
+		CREATE TABLE IF NOT EXISTS item_info (
+		    item_id VARCHAR(32) PRIMARY KEY NOT NULL,
+		    quality INTEGER NOT NULL DEFAULT 1,
+		    class_id INTEGER NOT NULL DEFAULT 0,
+		    subclass_id INTEGER NOT NULL DEFAULT 0,
+		    item_level INTEGER NOT NULL DEFAULT 0,
+		    required_level INTEGER NOT NULL DEFAULT 0,
+		    stack_size INTEGER NOT NULL DEFAULT 1,
+		    vendor_price INTEGER NOT NULL DEFAULT 0,
+		    binding INTEGER NOT NULL DEFAULT 0
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS item_info_class ON item_info (class_id, subclass_id)
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS item_class (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    name VARCHAR(64) NOT NULL
+		)
+		`,
+		`
+		INSERT INTO item_class (id, name) VALUES
+		(0, 'Consumable'),
+		(1, 'Container'),
+		(2, 'Weapon'),
+		(3, 'Gem'),
+		(4, 'Armor'),
+		(5, 'Reagent'),
+		(6, 'Projectile'),
+		(7, 'Trade Goods'),
+		(8, 'Generic'),
+		(9, 'Recipe'),
+		(10, 'Money'),
+		(11, 'Quiver'),
+		(12, 'Quest'),
+		(13, 'Key'),
+		(14, 'Permanent'),
+		(15, 'Miscellaneous')
+		`,
+		`
+		CREATE TABLE IF NOT EXISTS item_subclass (
+		    class_id INTEGER NOT NULL,
+		    id INTEGER NOT NULL,
+		    name VARCHAR(64) NOT NULL,
+		    PRIMARY KEY(class_id, id)
+		)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(11)
+		`,
+	},
+	12: {
+		// the quality reported by uploads, used for items the catalog does
+		// not have.
+		`
+		ALTER TABLE item ADD COLUMN quality INTEGER
+		`,
+		`
+		INSERT INTO migration (migration_id) values(12)
+		`,
+	},
+	13: {
+		// requests by guild members for items from the bank, see
+		// requestTransitions for the statuses they go through.
+		`
+		CREATE TABLE IF NOT EXISTS item_request (
+		    id INTEGER PRIMARY KEY NOT NULL,
+		    guild_id VARCHAR(32) NOT NULL,
+		    item_id VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    quantity INTEGER NOT NULL,
+		    note VARCHAR(255) NOT NULL DEFAULT '',
+		    requester_id VARCHAR(32) NOT NULL,
+		    status VARCHAR(16) NOT NULL,
+		    decided_by VARCHAR(32) NOT NULL DEFAULT '',
+		    channel_id VARCHAR(32) NOT NULL DEFAULT '',
+		    message_id VARCHAR(32) NOT NULL DEFAULT '',
+		    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		    FOREIGN KEY(item_id) REFERENCES item(id)
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS item_request_guild_status ON item_request (guild_id, status, id)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(13)
+		`,
+	},
+	14: {
+		// the full-text index was keyed on the implicit rowid of item, which
+		// VACUUM may renumber. It is rebuilt keyed on the item ID instead,
+		// stored but not indexed for matching.
+		`
+		DROP TABLE item_fts
+		`,
+		`
+		CREATE VIRTUAL TABLE item_fts USING fts4(name, item_id, notindexed=item_id, tokenize=unicode61)
+		`,
+		`
+		INSERT INTO item_fts (name, item_id) SELECT name, id FROM item
+		`,
+		`
+		INSERT INTO migration (migration_id) values(14)
+		`,
+	},
+	15: {
+		// catalog names are kept with the rest of the catalog metadata, as
+		// they need not be unique and item only holds items seen in uploads.
+		// Items only the catalog named before are dropped from item.
+		`
+		ALTER TABLE item_info ADD COLUMN name VARCHAR(255) NOT NULL DEFAULT ''
+		`,
+		`
+		UPDATE item_info SET name = (SELECT name FROM item WHERE id = item_info.item_id)
+		WHERE item_id IN (SELECT id FROM item)
+		`,
+		`
+		DELETE FROM item_fts WHERE item_id IN (
+		    SELECT id FROM item
+		    WHERE id IN (SELECT item_id FROM item_info)
+		    AND id NOT IN (SELECT item_id FROM inventory_snapshot_item)
+		    AND id NOT IN (SELECT item_id FROM item_request)
+		)
+		`,
+		`
+		DELETE FROM item
+		WHERE id IN (SELECT item_id FROM item_info)
+		AND id NOT IN (SELECT item_id FROM inventory_snapshot_item)
+		AND id NOT IN (SELECT item_id FROM item_request)
+		`,
+		`
+		INSERT INTO migration (migration_id) values(15)
+		`,
+	},
+	16: {
+		// the bank characters of each audit log entry, owner holds them
+		// comma separated as shown to users
+		`
+		CREATE TABLE IF NOT EXISTS audit_log_owner (
+		    audit_id INTEGER NOT NULL,
+		    name VARCHAR(64) NOT NULL COLLATE NOCASE,
+		    realm VARCHAR(64) NOT NULL DEFAULT '' COLLATE NOCASE,
+		    FOREIGN KEY(audit_id) REFERENCES audit_log(id)
+		)
+		`,
+		`
+		CREATE INDEX IF NOT EXISTS audit_log_owner_name ON audit_log_owner (name, realm)
+		`,
+		`
+		WITH RECURSIVE split(audit_id, owner, rest) AS (
+		    SELECT id, '', owner || ', ' FROM audit_log WHERE owner != ''
+		    UNION ALL
+		    SELECT audit_id, substr(rest, 1, instr(rest, ', ') - 1), substr(rest, instr(rest, ', ') + 2) FROM split
+		    WHERE rest != ''
+		)
+		INSERT INTO audit_log_owner (audit_id, name, realm)
+		SELECT audit_id,
+		    CASE WHEN instr(owner, '-') > 0 THEN substr(owner, 1, instr(owner, '-') - 1) ELSE owner END,
+		    CASE WHEN instr(owner, '-') > 0 THEN substr(owner, instr(owner, '-') + 1) ELSE '' END
+		FROM split
+		WHERE owner != ''
+		`,
+		`
+		INSERT INTO migration (migration_id) values(16)
+		`,
+	},
 }
 
 type Migrator struct {
@@ -98,3 +542,59 @@ func (m *Migrator) Migrate() error {
 
 	return err
 }
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	ID        int
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Status returns the status of every known migration in order, along with any
+// applied migration unknown to this build.
+func (m *Migrator) Status() ([]*MigrationStatus, error) {
+	applied := map[int]time.Time{}
+
+	r, err := m.db.Query(`SELECT migration_id, updated_at FROM migration`)
+	switch {
+	case err != nil && err.Error() != "no such table: migration":
+		return nil, err
+	case err == nil:
+		defer func() { _ = r.Close() }()
+
+		for r.Next() {
+			var id int
+			var at time.Time
+			if err := r.Scan(&id, &at); err != nil {
+				return nil, err
+			}
+
+			applied[id] = at
+		}
+
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var ids []int
+	for id := range Migrations {
+		ids = append(ids, id)
+	}
+
+	for id := range applied {
+		if _, ok := Migrations[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Ints(ids)
+
+	var status []*MigrationStatus
+	for _, id := range ids {
+		at, ok := applied[id]
+		status = append(status, &MigrationStatus{ID: id, Applied: ok, AppliedAt: at})
+	}
+
+	return status, nil
+}
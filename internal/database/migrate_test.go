@@ -1,6 +1,8 @@
 package database_test
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 
 	"github.com/jbweber/gringotts-bot/internal/database"
@@ -19,7 +21,7 @@ func TestMigrator_Migrate(t *testing.T) {
 
 	id, err := m.GetLatestMigrationID()
 	require.NoError(t, err)
-	require.Equal(t, 2, id)
+	require.Equal(t, 16, id)
 }
 
 func TestMigrator_GetLatestMigrationID(t *testing.T) {
@@ -57,3 +59,111 @@ func TestMigrator_GetLatestMigrationID(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 3, id)
 }
+
+func TestMigrator_MigrateExistingItemCounts(t *testing.T) {
+	db, err := database.NewDB("file:TestMigrator_MigrateExistingItemCounts?mode=memory&cache=shared")
+	require.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	for _, id := range []int{1, 2} {
+		for _, q := range database.Migrations[id] {
+			_, err = db.Exec(q)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = db.Exec("INSERT INTO item (id, name) values ('1', 'item 1')")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO item_count (owner, item_id, item_count) values ('testChar', '1', 7)")
+	require.NoError(t, err)
+
+	m := database.NewMigrator(db)
+	err = m.Migrate()
+	require.NoError(t, err)
+
+	g := database.NewGringotts(db)
+
+	// rows from before guilds were tracked belong to no guild until assigned
+	_, err = g.GetItemCount(context.Background(), testGuild, database.Owner{Name: "testChar"}, 1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the snapshot and the bank character created for its owner
+	assigned, err := g.AssignGuild(context.Background(), testGuild)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), assigned)
+
+	count, err := g.GetItemCount(context.Background(), testGuild, database.Owner{Name: "testChar"}, 1)
+	require.NoError(t, err)
+	require.Equal(t, 7, count)
+
+	result, err := g.SearchItems(context.Background(), testGuild, "item", database.QualityPoor, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, 7, result.Items[0].Count)
+}
+
+func TestMigrator_MigrateExistingAuditOwners(t *testing.T) {
+	db, err := database.NewDB("file:TestMigrator_MigrateExistingAuditOwners?mode=memory&cache=shared")
+	require.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	for id := 1; id < 16; id++ {
+		for _, q := range database.Migrations[id] {
+			_, err = db.Exec(q)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO audit_log (user_id, username, guild_id, command, options, owner, outcome) VALUES
+		('100', 'officer', ?, 'load-inventory', '{}', 'Bankalt-Azjol-Nerub, Otheralt', 'success'),
+		('100', 'officer', ?, 'gbank', '{}', '', 'success')
+		`, testGuild, testGuild,
+	)
+	require.NoError(t, err)
+
+	m := database.NewMigrator(db)
+	err = m.Migrate()
+	require.NoError(t, err)
+
+	g := database.NewGringotts(db)
+
+	for _, owner := range []string{"bankalt", "Bankalt-Azjol-Nerub", "otheralt"} {
+		found, err := g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{Owner: owner})
+		require.NoError(t, err)
+		require.Len(t, found, 1, owner)
+	}
+
+	found, err := g.FindAuditEntries(context.Background(), testGuild, database.AuditFilter{Owner: "Bankalt-Azjol"})
+	require.NoError(t, err)
+	require.Empty(t, found)
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, err := database.NewDB("file::memory:?cache=shared")
+	require.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	m := database.NewMigrator(db)
+	status, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, status, len(database.Migrations))
+	for _, s := range status {
+		require.False(t, s.Applied)
+	}
+
+	require.NoError(t, m.Migrate())
+
+	status, err = m.Status()
+	require.NoError(t, err)
+	require.Len(t, status, len(database.Migrations))
+	for k, s := range status {
+		require.Equal(t, k+1, s.ID)
+		require.True(t, s.Applied)
+		require.False(t, s.AppliedAt.IsZero())
+	}
+}
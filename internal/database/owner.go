@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Game flavors a bank character can play. A character stored before flavors
+// were tracked has no flavor.
+const (
+	FlavorClassic  = "classic"
+	FlavorSoD      = "sod"
+	FlavorHardcore = "hardcore"
+	FlavorCata     = "cata"
+	FlavorRetail   = "retail"
+)
+
+// Flavors lists every game flavor.
+var Flavors = []string{FlavorClassic, FlavorSoD, FlavorHardcore, FlavorCata, FlavorRetail}
+
+// ErrAmbiguousOwner is returned when an owner given without a realm or flavor
+// matches more than one bank character.
+var ErrAmbiguousOwner = errors.New("more than one bank character matches, the realm is needed to tell them apart")
+
+// Owner identifies the bank character holding items. Characters are unique by
+// name, realm and flavor within a guild, the faction is informational. An
+// empty realm or flavor matches any when looking a character up.
+type Owner struct {
+	Name    string
+	Realm   string
+	Faction string
+	Flavor  string
+}
+
+// String returns the name and realm as shown in game.
+func (o Owner) String() string {
+	if o.Realm == "" {
+		return o.Name
+	}
+
+	return o.Name + "-" + o.Realm
+}
+
+// querier runs queries on a database or in a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// matchCharacters returns the IDs of the bank characters of guildID that owner
+// may refer to. Characters stored without a realm or flavor match any, but
+// when one matches the realm and flavor exactly only it is returned.
+func matchCharacters(ctx context.Context, q querier, guildID string, owner Owner) ([]int64, error) {
+	r, err := q.QueryContext(ctx, `
+		SELECT id, realm, flavor FROM bank_character
+		WHERE guild_id = ? AND name = ?
+		AND (? = '' OR realm = '' OR realm = ?)
+		AND (? = '' OR flavor = '' OR flavor = ?)
+		ORDER BY id
+		`, guildID, owner.Name, owner.Realm, owner.Realm, owner.Flavor, owner.Flavor,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var ids, exact []int64
+	for r.Next() {
+		var id int64
+		var realm, flavor string
+		if err := r.Scan(&id, &realm, &flavor); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+		if strings.EqualFold(realm, owner.Realm) && flavor == owner.Flavor {
+			exact = append(exact, id)
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(exact) > 0 {
+		return exact, nil
+	}
+
+	return ids, nil
+}
+
+// resolveCharacter returns the ID of the bank character of guildID owner
+// refers to, creating it if there is none. A realm, faction or flavor the
+// stored character is missing is filled in from owner.
+func resolveCharacter(ctx context.Context, q querier, guildID string, owner Owner) (int64, error) {
+	ids, err := matchCharacters(ctx, q, guildID, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(ids) {
+	case 0:
+		res, err := q.ExecContext(ctx, `
+			INSERT INTO bank_character (guild_id, name, realm, faction, flavor) VALUES (?,?,?,?,?)
+			`, guildID, owner.Name, owner.Realm, owner.Faction, owner.Flavor,
+		)
+		if err != nil {
+			return 0, err
+		}
+
+		return res.LastInsertId()
+	case 1:
+		_, err := q.ExecContext(ctx, `
+			UPDATE bank_character SET
+				realm = CASE WHEN realm = '' THEN ? ELSE realm END,
+				faction = CASE WHEN ? = '' THEN faction ELSE ? END,
+				flavor = CASE WHEN flavor = '' THEN ? ELSE flavor END
+			WHERE id = ?
+			`, owner.Realm, owner.Faction, owner.Faction, owner.Flavor, ids[0],
+		)
+
+		return ids[0], err
+	default:
+		return 0, ErrAmbiguousOwner
+	}
+}
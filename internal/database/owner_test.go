@@ -0,0 +1,86 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_OwnersOnDifferentRealms(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), map[string]string{"1": "Linen Cloth"})
+	require.NoError(t, err)
+
+	mankrik := database.Owner{Name: "Gbank", Realm: "Mankrik", Faction: database.FactionHorde, Flavor: database.FlavorClassic}
+	pagle := database.Owner{Name: "Gbank", Realm: "Pagle", Faction: database.FactionAlliance, Flavor: database.FlavorClassic}
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, mankrik, map[string]int{"1": 20})
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, pagle, map[string]int{"1": 5})
+	require.NoError(t, err)
+
+	count, err := g.GetItemCount(context.Background(), testGuild, mankrik, 1)
+	require.NoError(t, err)
+	require.Equal(t, 20, count)
+
+	count, err = g.GetItemCount(context.Background(), testGuild, database.Owner{Name: "gbank", Realm: "pagle"}, 1)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+
+	_, err = g.GetItemCount(context.Background(), testGuild, database.Owner{Name: "Gbank"}, 1)
+	require.ErrorIs(t, err, database.ErrAmbiguousOwner)
+
+	items, err := g.FindItem(context.Background(), testGuild, "linen", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, 25, items[0].Count)
+	require.Equal(t, []*database.Holder{{Owner: mankrik, Count: 20}, {Owner: pagle, Count: 5}}, items[0].Holders)
+}
+
+func TestGringotts_RegisterClaimsUploadedOwner(t *testing.T) {
+	g, db := getGringotts(t)
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "Gbank"}, map[string]int{"1": 1})
+	require.NoError(t, err)
+
+	err = g.RegisterBankCharacter(context.Background(), testGuild, &database.BankCharacter{Name: "Gbank", Realm: "Mankrik", Faction: database.FactionHorde}, "100")
+	require.NoError(t, err)
+
+	characters, err := g.ListBankCharacters(context.Background(), testGuild)
+	require.NoError(t, err)
+	require.Len(t, characters, 1)
+	require.Equal(t, "Mankrik", characters[0].Realm)
+
+	// an upload that names the realm and flavor fills in the flavor
+	owner := database.Owner{Name: "Gbank", Realm: "Mankrik", Faction: database.FactionHorde, Flavor: database.FlavorSoD}
+	ok, err := g.IsBankCharacterUser(context.Background(), testGuild, owner, "100")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, owner, map[string]int{"1": 2})
+	require.NoError(t, err)
+
+	snapshots, err := g.ListSnapshots(context.Background(), testGuild, database.Owner{Name: "Gbank"}, 10)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, owner, snapshots[0].Owner)
+
+	// the snapshots keep the character after it is removed
+	removed, err := g.RemoveBankCharacter(context.Background(), testGuild, owner)
+	require.NoError(t, err)
+	require.True(t, removed)
+
+	ok, err = g.IsBankCharacterUser(context.Background(), testGuild, owner, "100")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	count, err := g.GetItemCount(context.Background(), testGuild, owner, 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
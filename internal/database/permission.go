@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// Capabilities that can be granted to a Discord role. Each capability includes
+// the ones before it, admin can upload and upload can read.
+const (
+	CapabilityRead   = "read"
+	CapabilityUpload = "upload"
+	CapabilityAdmin  = "admin"
+)
+
+// RoleCapability is a capability granted to a Discord role.
+type RoleCapability struct {
+	RoleID     string
+	Capability string
+}
+
+// GrantCapability grants capability to the role in guildID. Granting a
+// capability the role already holds is not an error.
+func (g *Gringotts) GrantCapability(ctx context.Context, guildID, roleID string, capability string) error {
+	_, err := g.db.ExecContext(ctx, `INSERT INTO role_capability (guild_id, role_id, capability) VALUES (?,?,?) ON CONFLICT(guild_id, role_id, capability) DO NOTHING`, guildID, roleID, capability)
+
+	return err
+}
+
+// RevokeCapability removes capability from the role in guildID, reporting
+// whether the role held it.
+func (g *Gringotts) RevokeCapability(ctx context.Context, guildID, roleID string, capability string) (bool, error) {
+	res, err := g.db.ExecContext(ctx, `DELETE FROM role_capability WHERE guild_id = ? AND role_id = ? AND capability = ?`, guildID, roleID, capability)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// ListRoleCapabilities returns every capability granted to any role in
+// guildID.
+func (g *Gringotts) ListRoleCapabilities(ctx context.Context, guildID string) ([]*RoleCapability, error) {
+	r, err := g.db.QueryContext(ctx, `SELECT role_id, capability FROM role_capability WHERE guild_id = ? ORDER BY role_id, capability`, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var capabilities []*RoleCapability
+	for r.Next() {
+		c := &RoleCapability{}
+		if err := r.Scan(&c.RoleID, &c.Capability); err != nil {
+			return nil, err
+		}
+
+		capabilities = append(capabilities, c)
+	}
+
+	return capabilities, r.Err()
+}
+
+// GetCapabilities returns the distinct capabilities granted to any of roleIDs
+// in guildID.
+func (g *Gringotts) GetCapabilities(ctx context.Context, guildID string, roleIDs []string) ([]string, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	args := []any{guildID}
+	for _, v := range roleIDs {
+		args = append(args, v)
+	}
+
+	r, err := g.db.QueryContext(ctx, `
+		SELECT DISTINCT capability FROM role_capability
+		WHERE guild_id = ?
+		AND role_id IN (?`+strings.Repeat(",?", len(roleIDs)-1)+`)
+		ORDER BY capability
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var capabilities []string
+	for r.Next() {
+		var c string
+		if err := r.Scan(&c); err != nil {
+			return nil, err
+		}
+
+		capabilities = append(capabilities, c)
+	}
+
+	return capabilities, r.Err()
+}
@@ -0,0 +1,55 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_GrantCapability(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	err := g.GrantCapability(context.Background(), testGuild, "10", database.CapabilityUpload)
+	require.NoError(t, err)
+
+	err = g.GrantCapability(context.Background(), testGuild, "10", database.CapabilityUpload)
+	require.NoError(t, err)
+
+	err = g.GrantCapability(context.Background(), testGuild, "20", database.CapabilityAdmin)
+	require.NoError(t, err)
+
+	err = g.GrantCapability(context.Background(), testGuild, "20", database.CapabilityRead)
+	require.NoError(t, err)
+
+	capabilities, err := g.ListRoleCapabilities(context.Background(), testGuild)
+	require.NoError(t, err)
+	require.Equal(t, []*database.RoleCapability{
+		{RoleID: "10", Capability: database.CapabilityUpload},
+		{RoleID: "20", Capability: database.CapabilityAdmin},
+		{RoleID: "20", Capability: database.CapabilityRead},
+	}, capabilities)
+
+	held, err := g.GetCapabilities(context.Background(), testGuild, []string{"10", "30"})
+	require.NoError(t, err)
+	require.Equal(t, []string{database.CapabilityUpload}, held)
+
+	held, err = g.GetCapabilities(context.Background(), testGuild, nil)
+	require.NoError(t, err)
+	require.Empty(t, held)
+
+	revoked, err := g.RevokeCapability(context.Background(), testGuild, "20", database.CapabilityAdmin)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = g.RevokeCapability(context.Background(), testGuild, "20", database.CapabilityAdmin)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	held, err = g.GetCapabilities(context.Background(), testGuild, []string{"10", "20"})
+	require.NoError(t, err)
+	require.Equal(t, []string{database.CapabilityRead, database.CapabilityUpload}, held)
+}
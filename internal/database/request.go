@@ -0,0 +1,295 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Item request statuses. Requests start open, officers approve or deny them
+// and approved requests are fulfilled once the items are handed over. Open and
+// approved requests can be cancelled, the other statuses are final.
+const (
+	RequestOpen      = "open"
+	RequestApproved  = "approved"
+	RequestDenied    = "denied"
+	RequestFulfilled = "fulfilled"
+	RequestCancelled = "cancelled"
+)
+
+// RequestStatuses are the item request statuses in workflow order.
+var RequestStatuses = []string{RequestOpen, RequestApproved, RequestDenied, RequestFulfilled, RequestCancelled}
+
+// requestTransitions are the statuses a request in each status can move to.
+var requestTransitions = map[string][]string{
+	RequestOpen:     {RequestApproved, RequestDenied, RequestCancelled},
+	RequestApproved: {RequestFulfilled, RequestCancelled},
+}
+
+var (
+	// ErrNoRequest is returned when no item request matches a lookup.
+	ErrNoRequest = errors.New("no item request found")
+
+	// ErrRequestTransition is returned when a request can not move to the
+	// status asked for.
+	ErrRequestTransition = errors.New("invalid request status change")
+)
+
+// ItemRequest is a guild member asking for items from the bank.
+type ItemRequest struct {
+	ID          int64
+	ItemID      string
+	ItemName    string
+	Quantity    int
+	Note        string
+	RequesterID string
+	Status      string
+	// DecidedBy is the user who last changed the status, empty while the
+	// request is open.
+	DecidedBy string
+	// ChannelID and MessageID locate the message the request was posted in,
+	// empty until SetRequestMessage is called.
+	ChannelID string
+	MessageID string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CanBecome reports whether the request can move to status.
+func (r *ItemRequest) CanBecome(status string) bool {
+	for _, s := range requestTransitions[r.Status] {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequestFilter narrows the requests returned by ListRequests. Zero valued
+// fields are ignored.
+type RequestFilter struct {
+	Status      string
+	RequesterID string
+	ItemID      string
+	Limit       int
+}
+
+// requestColumns are the columns scanned by scanRequest, with item_request r
+// and item i joined.
+const requestColumns = `r.id, r.item_id, COALESCE(i.name, ''), r.quantity, r.note, r.requester_id, r.status,
+	r.decided_by, r.channel_id, r.message_id, r.created_at, r.updated_at`
+
+// CreateRequest stores a new open request by requesterID for quantity of
+// itemID in guildID.
+func (g *Gringotts) CreateRequest(ctx context.Context, guildID, itemID string, quantity int, note, requesterID string) (*ItemRequest, error) {
+	if quantity < 1 {
+		return nil, fmt.Errorf("invalid quantity %d", quantity)
+	}
+
+	res, err := g.db.ExecContext(ctx, `
+		INSERT INTO item_request (guild_id, item_id, quantity, note, requester_id, status)
+		VALUES (?,?,?,?,?,?)
+		`, guildID, itemID, quantity, note, requesterID, RequestOpen,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return g.GetRequest(ctx, guildID, id)
+}
+
+// GetRequest returns request id of guildID, or ErrNoRequest.
+func (g *Gringotts) GetRequest(ctx context.Context, guildID string, id int64) (*ItemRequest, error) {
+	return getRequest(ctx, g.db, guildID, id)
+}
+
+func getRequest(ctx context.Context, q querier, guildID string, id int64) (*ItemRequest, error) {
+	r := q.QueryRowContext(ctx, `
+		SELECT `+requestColumns+` FROM item_request r
+		LEFT JOIN item i
+		ON i.id = r.item_id
+		WHERE r.guild_id = ? AND r.id = ?
+		`, guildID, id,
+	)
+
+	req, err := scanRequest(r)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoRequest
+	}
+
+	return req, err
+}
+
+// ListRequests returns the requests of guildID matching f, newest first.
+func (g *Gringotts) ListRequests(ctx context.Context, guildID string, f RequestFilter) ([]*ItemRequest, error) {
+	where := []string{"r.guild_id = ?"}
+	args := []any{guildID}
+
+	if f.Status != "" {
+		where = append(where, "r.status = ?")
+		args = append(args, f.Status)
+	}
+
+	if f.RequesterID != "" {
+		where = append(where, "r.requester_id = ?")
+		args = append(args, f.RequesterID)
+	}
+
+	if f.ItemID != "" {
+		where = append(where, "r.item_id = ?")
+		args = append(args, f.ItemID)
+	}
+
+	query := `SELECT ` + requestColumns + ` FROM item_request r LEFT JOIN item i ON i.id = r.item_id`
+	query += " WHERE " + strings.Join(where, " AND ")
+	query += " ORDER BY r.id DESC"
+
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	r, err := g.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var requests []*ItemRequest
+	for r.Next() {
+		req, err := scanRequest(r)
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, req)
+	}
+
+	return requests, r.Err()
+}
+
+// UpdateRequestStatus moves request id of guildID to status on behalf of
+// userID and returns the updated request. It returns an error wrapping
+// ErrRequestTransition if the request can not move to status.
+func (g *Gringotts) UpdateRequestStatus(ctx context.Context, guildID string, id int64, status, userID string) (*ItemRequest, error) {
+	tx, err := g.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := getRequest(ctx, tx, guildID, id)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return nil, err
+	}
+
+	if !req.CanBecome(status) {
+		_ = tx.Rollback() // TODO multierr
+		return nil, fmt.Errorf("%w: request %d is %s and can not become %s", ErrRequestTransition, id, req.Status, status)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE item_request SET status = ?, decided_by = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+		`, status, userID, id,
+	)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return nil, err
+	}
+
+	req, err = getRequest(ctx, tx, guildID, id)
+	if err != nil {
+		_ = tx.Rollback() // TODO multierr
+		return nil, err
+	}
+
+	return req, tx.Commit()
+}
+
+// SetRequestMessage records the message request id of guildID was posted in.
+func (g *Gringotts) SetRequestMessage(ctx context.Context, guildID string, id int64, channelID, messageID string) error {
+	_, err := g.db.ExecContext(ctx, `
+		UPDATE item_request SET channel_id = ?, message_id = ? WHERE guild_id = ? AND id = ?
+		`, channelID, messageID, guildID, id,
+	)
+
+	return err
+}
+
+func scanRequest(r interface{ Scan(...any) error }) (*ItemRequest, error) {
+	req := &ItemRequest{}
+	err := r.Scan(&req.ID, &req.ItemID, &req.ItemName, &req.Quantity, &req.Note, &req.RequesterID, &req.Status,
+		&req.DecidedBy, &req.ChannelID, &req.MessageID, &req.CreatedAt, &req.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// FulfilledRequests returns the approved requests of guildID that items
+// leaving the bank may have fulfilled, given how many of each item ID left.
+// Requests are matched oldest first while enough of their item is left to
+// cover them, so an upload never fulfills more than it accounts for.
+func (g *Gringotts) FulfilledRequests(ctx context.Context, guildID string, drops map[string]int) ([]*ItemRequest, error) {
+	ids := make([]string, 0, len(drops))
+	for id, n := range drops {
+		if n > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	args := []any{guildID, RequestApproved}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	r, err := g.db.QueryContext(ctx, `
+		SELECT `+requestColumns+` FROM item_request r
+		LEFT JOIN item i
+		ON i.id = r.item_id
+		WHERE r.guild_id = ? AND r.status = ? AND r.item_id IN (?`+strings.Repeat(",?", len(ids)-1)+`)
+		ORDER BY r.id
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	left := map[string]int{}
+	for id, n := range drops {
+		left[id] = n
+	}
+
+	var requests []*ItemRequest
+	for r.Next() {
+		req, err := scanRequest(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.Quantity <= left[req.ItemID] {
+			left[req.ItemID] -= req.Quantity
+			requests = append(requests, req)
+		}
+	}
+
+	return requests, r.Err()
+}
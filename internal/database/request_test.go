@@ -0,0 +1,131 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_ItemRequests(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), items1)
+	require.NoError(t, err)
+
+	flasks, err := g.CreateRequest(context.Background(), testGuild, "1", 5, "for naxx", "100")
+	require.NoError(t, err)
+	require.Equal(t, "item 1", flasks.ItemName)
+	require.Equal(t, 5, flasks.Quantity)
+	require.Equal(t, database.RequestOpen, flasks.Status)
+
+	_, err = g.CreateRequest(context.Background(), testGuild, "2", 0, "", "100")
+	require.Error(t, err)
+
+	herbs, err := g.CreateRequest(context.Background(), testGuild, "2", 20, "", "200")
+	require.NoError(t, err)
+
+	_, err = g.CreateRequest(context.Background(), "2", "2", 1, "", "200")
+	require.NoError(t, err)
+
+	// open requests can not be fulfilled before an officer approves them
+	_, err = g.UpdateRequestStatus(context.Background(), testGuild, flasks.ID, database.RequestFulfilled, "300")
+	require.ErrorIs(t, err, database.ErrRequestTransition)
+
+	flasks, err = g.UpdateRequestStatus(context.Background(), testGuild, flasks.ID, database.RequestApproved, "300")
+	require.NoError(t, err)
+	require.Equal(t, database.RequestApproved, flasks.Status)
+	require.Equal(t, "300", flasks.DecidedBy)
+
+	flasks, err = g.UpdateRequestStatus(context.Background(), testGuild, flasks.ID, database.RequestFulfilled, "300")
+	require.NoError(t, err)
+	require.Equal(t, database.RequestFulfilled, flasks.Status)
+
+	_, err = g.UpdateRequestStatus(context.Background(), testGuild, flasks.ID, database.RequestCancelled, "100")
+	require.ErrorIs(t, err, database.ErrRequestTransition)
+
+	_, err = g.UpdateRequestStatus(context.Background(), testGuild, herbs.ID, database.RequestDenied, "300")
+	require.NoError(t, err)
+
+	// requests of other guilds are not found
+	_, err = g.UpdateRequestStatus(context.Background(), "2", herbs.ID, database.RequestCancelled, "200")
+	require.ErrorIs(t, err, database.ErrNoRequest)
+
+	err = g.SetRequestMessage(context.Background(), testGuild, herbs.ID, "10", "11")
+	require.NoError(t, err)
+
+	herbs, err = g.GetRequest(context.Background(), testGuild, herbs.ID)
+	require.NoError(t, err)
+	require.Equal(t, "11", herbs.MessageID)
+
+	requests, err := g.ListRequests(context.Background(), testGuild, database.RequestFilter{})
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	require.Equal(t, herbs.ID, requests[0].ID)
+
+	requests, err = g.ListRequests(context.Background(), testGuild, database.RequestFilter{Status: database.RequestFulfilled})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	require.Equal(t, flasks.ID, requests[0].ID)
+
+	requests, err = g.ListRequests(context.Background(), testGuild, database.RequestFilter{RequesterID: "200", ItemID: "2"})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	require.Equal(t, database.RequestDenied, requests[0].Status)
+
+	requests, err = g.ListRequests(context.Background(), "2", database.RequestFilter{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+}
+
+func TestGringotts_FulfilledRequests(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), items1)
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, r := range []struct {
+		itemID   string
+		quantity int
+	}{
+		{itemID: "1", quantity: 5},
+		{itemID: "1", quantity: 10},
+		{itemID: "1", quantity: 3},
+		{itemID: "2", quantity: 1},
+		{itemID: "3", quantity: 1},
+	} {
+		req, err := g.CreateRequest(context.Background(), testGuild, r.itemID, r.quantity, "", "100")
+		require.NoError(t, err)
+
+		_, err = g.UpdateRequestStatus(context.Background(), testGuild, req.ID, database.RequestApproved, "300")
+		require.NoError(t, err)
+
+		ids = append(ids, req.ID)
+	}
+
+	// open requests are not matched
+	open, err := g.CreateRequest(context.Background(), testGuild, "2", 1, "", "100")
+	require.NoError(t, err)
+
+	requests, err := g.FulfilledRequests(context.Background(), testGuild, map[string]int{"1": 9, "2": 5, "4": 1})
+	require.NoError(t, err)
+
+	var matched []int64
+	for _, r := range requests {
+		matched = append(matched, r.ID)
+	}
+
+	// the 10 does not fit in what is left after the 5, the 3 still does
+	require.Equal(t, []int64{ids[0], ids[2], ids[3]}, matched)
+	require.NotContains(t, matched, open.ID)
+
+	requests, err = g.FulfilledRequests(context.Background(), "2", map[string]int{"1": 9})
+	require.NoError(t, err)
+	require.Empty(t, requests)
+}
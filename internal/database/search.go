@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxSuggestions is the number of "did you mean" names returned when a search
+// has no matches.
+const maxSuggestions = 5
+
+// ItemSearchResult is the result of SearchItems. Suggestions is only populated
+// when no items matched.
+type ItemSearchResult struct {
+	Items       []*Item
+	Suggestions []string
+}
+
+// SearchItems searches for items of at least minQuality by name, returning at
+// most limit results after skipping offset. Substring matches are tried first,
+// then a full-text match where every word of searchString must prefix a word of
+// the item name in any order. If neither finds anything the names closest to
+// searchString by edit distance are returned as suggestions.
+func (g *Gringotts) SearchItems(ctx context.Context, guildID, searchString string, minQuality, limit, offset int) (*ItemSearchResult, error) {
+	for _, find := range []func(context.Context, string, string, int, int, int) ([]*Item, error){g.findItems, g.matchItems} {
+		items, err := find(ctx, guildID, searchString, minQuality, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(items) > 0 {
+			return &ItemSearchResult{Items: items}, nil
+		}
+
+		if offset > 0 {
+			// past the last page, stay with this search if it matched on the
+			// first page so paging does not fall through to the next one
+			first, err := find(ctx, guildID, searchString, minQuality, 1, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(first) > 0 {
+				return &ItemSearchResult{}, nil
+			}
+		}
+	}
+
+	suggestions, err := g.suggestItemNames(ctx, searchString, maxSuggestions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ItemSearchResult{Suggestions: suggestions}, nil
+}
+
+// matchItems runs a prefix match for every word in searchString against the
+// full-text item index, leaving out items below minQuality.
+func (g *Gringotts) matchItems(ctx context.Context, guildID, searchString string, minQuality, limit, offset int) ([]*Item, error) {
+	tokens := tokenize(searchString)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	for k, v := range tokens {
+		tokens[k] = v + "*"
+	}
+
+	r, err := g.db.QueryContext(ctx, `
+		SELECT i.id, i.name, COALESCE(SUM(ic.item_count), 0) as item_total, `+itemQuality+` FROM item_fts f
+		JOIN item i
+		ON i.id = f.item_id
+		LEFT JOIN item_info ii
+		ON ii.item_id = i.id
+		LEFT JOIN item_count ic
+		ON i.id = ic.item_id
+		AND ic.guild_id = ?
+		WHERE item_fts MATCH ?
+		AND `+itemQuality+` >= ?
+		GROUP BY i.id
+		ORDER BY `+itemQuality+` DESC, i.name
+		LIMIT ? OFFSET ?
+		`, guildID, strings.Join(tokens, " "), minQuality, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := scanItems(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.findHolders(ctx, guildID, items); err != nil {
+		return nil, err
+	}
+
+	return items, g.EnrichItems(ctx, items)
+}
+
+// suggestItemNames returns up to limit item names within a small edit distance
+// of searchString, closest first.
+func (g *Gringotts) suggestItemNames(ctx context.Context, searchString string, limit int) ([]string, error) {
+	needle := strings.Join(tokenize(searchString), " ")
+	if needle == "" {
+		return nil, nil
+	}
+
+	r, err := g.db.QueryContext(ctx, `SELECT name FROM item`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	maxDistance := len(needle)/4 + 1
+
+	var candidates []candidate
+	for r.Next() {
+		var name string
+		if err := r.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		d := levenshtein(needle, strings.Join(tokenize(name), " "))
+		if d <= maxDistance {
+			candidates = append(candidates, candidate{name: name, distance: d})
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].distance != candidates[b].distance {
+			return candidates[a].distance < candidates[b].distance
+		}
+		return candidates[a].name < candidates[b].name
+	})
+
+	var names []string
+	for k := 0; k < len(candidates) && k < limit; k++ {
+		names = append(names, candidates[k].name)
+	}
+
+	return names, nil
+}
+
+// tokenize lower cases s and splits it into words of letters and digits. It is
+// also used to sanitize input before it is handed to MATCH.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
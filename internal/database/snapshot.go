@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNoSnapshot is returned when no inventory snapshot matches a lookup.
+var ErrNoSnapshot = errors.New("no inventory snapshot found")
+
+// Snapshot is a single inventory upload for an owner.
+type Snapshot struct {
+	ID        int64
+	Owner     Owner
+	CreatedAt time.Time
+}
+
+// ItemDiff is the change in the total count of an item between two snapshots.
+type ItemDiff struct {
+	ID     string
+	Name   string
+	Before int
+	After  int
+}
+
+// Delta returns the change in count, negative when items were removed.
+func (d *ItemDiff) Delta() int {
+	return d.After - d.Before
+}
+
+// ListSnapshots returns up to limit snapshots for owner in guildID, newest
+// first.
+func (g *Gringotts) ListSnapshots(ctx context.Context, guildID string, owner Owner, limit int) ([]*Snapshot, error) {
+	characterID, err := g.ownerCharacter(ctx, guildID, owner)
+	if err != nil || characterID == 0 {
+		return nil, err
+	}
+
+	r, err := g.db.QueryContext(ctx, `
+		SELECT s.id, c.name, c.realm, c.faction, c.flavor, s.created_at FROM inventory_snapshot s
+		JOIN bank_character c
+		ON c.id = s.character_id
+		WHERE s.character_id = ?
+		ORDER BY s.id DESC
+		LIMIT ?
+		`, characterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var snapshots []*Snapshot
+	for r.Next() {
+		s := &Snapshot{}
+		if err := r.Scan(&s.ID, &s.Owner.Name, &s.Owner.Realm, &s.Owner.Faction, &s.Owner.Flavor, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, r.Err()
+}
+
+// GetSnapshotAt returns the snapshot that was current for owner in guildID at
+// the given time, or ErrNoSnapshot if owner had not uploaded anything yet.
+func (g *Gringotts) GetSnapshotAt(ctx context.Context, guildID string, owner Owner, at time.Time) (*Snapshot, error) {
+	characterID, err := g.ownerCharacter(ctx, guildID, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	r := g.db.QueryRowContext(ctx, `
+		SELECT s.id, c.name, c.realm, c.faction, c.flavor, s.created_at FROM inventory_snapshot s
+		JOIN bank_character c
+		ON c.id = s.character_id
+		WHERE s.character_id = ? AND s.created_at <= ?
+		ORDER BY s.created_at DESC, s.id DESC
+		LIMIT 1
+		`, characterID, at.UTC().Format(time.DateTime),
+	)
+
+	s := &Snapshot{}
+	err = r.Scan(&s.ID, &s.Owner.Name, &s.Owner.Realm, &s.Owner.Faction, &s.Owner.Flavor, &s.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSnapshot
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ownerCharacter returns the ID of the bank character owner refers to, or 0 if
+// there is none.
+func (g *Gringotts) ownerCharacter(ctx context.Context, guildID string, owner Owner) (int64, error) {
+	ids, err := matchCharacters(ctx, g.db, guildID, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, nil
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, ErrAmbiguousOwner
+	}
+}
+
+// GetSnapshotItems returns the item counts recorded in a snapshot of guildID by
+// location.
+func (g *Gringotts) GetSnapshotItems(ctx context.Context, guildID string, snapshotID int64) ([]*ItemLocation, error) {
+	r, err := g.db.QueryContext(ctx, `
+		SELECT si.item_id, COALESCE(i.name, ''), c.name, c.realm, c.faction, c.flavor, si.location, si.item_count FROM inventory_snapshot_item si
+		JOIN inventory_snapshot s
+		ON s.id = si.snapshot_id
+		JOIN bank_character c
+		ON c.id = s.character_id
+		LEFT JOIN item i
+		ON i.id = si.item_id
+		WHERE si.snapshot_id = ? AND s.guild_id = ?
+		ORDER BY i.name, si.location
+		`, snapshotID, guildID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var locations []*ItemLocation
+	for r.Next() {
+		l := &ItemLocation{}
+		if err := r.Scan(&l.ID, &l.Name, &l.Owner.Name, &l.Owner.Realm, &l.Owner.Faction, &l.Owner.Flavor, &l.Location, &l.Count); err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, l)
+	}
+
+	return locations, r.Err()
+}
+
+// DiffSnapshots compares the item totals of two snapshots of guildID and
+// returns every item whose count changed. A fromID of 0 compares against an
+// empty inventory.
+func (g *Gringotts) DiffSnapshots(ctx context.Context, guildID string, fromID, toID int64) ([]*ItemDiff, error) {
+	r, err := g.db.QueryContext(ctx, `
+		SELECT d.item_id, COALESCE(i.name, ''), d.before, d.after FROM (
+			SELECT item_id,
+				SUM(CASE WHEN snapshot_id = ? THEN item_count ELSE 0 END) as before,
+				SUM(CASE WHEN snapshot_id = ? THEN item_count ELSE 0 END) as after
+			FROM inventory_snapshot_item
+			WHERE snapshot_id IN (SELECT id FROM inventory_snapshot WHERE id IN (?, ?) AND guild_id = ?)
+			GROUP BY item_id
+		) d
+		LEFT JOIN item i
+		ON i.id = d.item_id
+		WHERE d.before != d.after
+		ORDER BY i.name, d.item_id
+		`, fromID, toID, fromID, toID, guildID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = r.Close() }()
+
+	var diffs []*ItemDiff
+	for r.Next() {
+		d := &ItemDiff{}
+		if err := r.Scan(&d.ID, &d.Name, &d.Before, &d.After); err != nil {
+			return nil, err
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	return diffs, r.Err()
+}
@@ -0,0 +1,117 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGringotts_ListSnapshots(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	testOwner := database.Owner{Name: "testChar"}
+
+	err := g.UpdateItemCounts(context.Background(), testGuild, testOwner, itemCounts1)
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, testOwner, itemCounts2)
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, database.Owner{Name: "otherChar"}, itemCounts2)
+	require.NoError(t, err)
+
+	snapshots, err := g.ListSnapshots(context.Background(), testGuild, testOwner, 10)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.Greater(t, snapshots[0].ID, snapshots[1].ID)
+	require.Equal(t, testOwner.Name, snapshots[0].Owner.Name)
+
+	items, err := g.GetSnapshotItems(context.Background(), testGuild, snapshots[1].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+
+	counts := map[string]int{}
+	for _, i := range items {
+		counts[i.ID] = i.Count
+	}
+	require.Equal(t, itemCounts1, counts)
+
+	one, err := g.GetItemCount(context.Background(), testGuild, testOwner, 1)
+	require.NoError(t, err)
+	require.Equal(t, itemCounts2["1"], one)
+}
+
+func TestGringotts_GetSnapshotAt(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	testOwner := database.Owner{Name: "testChar"}
+
+	err := g.UpdateItemCounts(context.Background(), testGuild, testOwner, itemCounts1)
+	require.NoError(t, err)
+
+	err = g.UpdateItemCounts(context.Background(), testGuild, testOwner, itemCounts2)
+	require.NoError(t, err)
+
+	_, err = db.Exec("UPDATE inventory_snapshot SET created_at = '2023-11-07 20:00:00' WHERE id = 1")
+	require.NoError(t, err)
+
+	_, err = db.Exec("UPDATE inventory_snapshot SET created_at = '2023-11-14 20:00:00' WHERE id = 2")
+	require.NoError(t, err)
+
+	_, err = g.GetSnapshotAt(context.Background(), testGuild, testOwner, time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, database.ErrNoSnapshot)
+
+	s, err := g.GetSnapshotAt(context.Background(), testGuild, testOwner, time.Date(2023, 11, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), s.ID)
+	require.Equal(t, time.Date(2023, 11, 7, 20, 0, 0, 0, time.UTC), s.CreatedAt)
+
+	s, err = g.GetSnapshotAt(context.Background(), testGuild, testOwner, time.Date(2023, 11, 14, 20, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), s.ID)
+}
+
+func TestGringotts_DiffSnapshots(t *testing.T) {
+	g, db := getGringotts(t)
+
+	defer func() { _ = db.Close() }()
+
+	err := g.UpdateItems(context.Background(), items2)
+	require.NoError(t, err)
+
+	err = g.UpdateItemLocations(context.Background(), testGuild, database.Owner{Name: "testChar"}, map[string]map[string]int{
+		database.LocationBags: {"1": 1, "2": 2},
+		database.LocationBank: {"1": 1, "3": 3},
+	})
+	require.NoError(t, err)
+
+	err = g.UpdateItemLocations(context.Background(), testGuild, database.Owner{Name: "testChar"}, map[string]map[string]int{
+		database.LocationBags: {"1": 2, "3": 1},
+		database.LocationBank: {"3": 2, "4": 4},
+	})
+	require.NoError(t, err)
+
+	diffs, err := g.DiffSnapshots(context.Background(), testGuild, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+
+	require.Equal(t, "2", diffs[0].ID)
+	require.Equal(t, "item 2", diffs[0].Name)
+	require.Equal(t, 2, diffs[0].Before)
+	require.Equal(t, 0, diffs[0].After)
+	require.Equal(t, -2, diffs[0].Delta())
+
+	require.Equal(t, "4", diffs[1].ID)
+	require.Equal(t, 4, diffs[1].Delta())
+
+	diffs, err = g.DiffSnapshots(context.Background(), testGuild, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, diffs, 3)
+}
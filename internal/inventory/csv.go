@@ -0,0 +1,275 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// errNoCharName is returned by importers for formats that do not record the
+// character when no name was passed in Options.
+var errNoCharName = errors.New("the data does not name a character, a character name must be given")
+
+// PlainCSV imports "itemID,count,name" lines. A header line of exactly those
+// column names is optional, as are comment lines starting with #. A
+// "# character: Name" comment names the character, otherwise Options.CharName
+// is used.
+type PlainCSV struct{}
+
+func (PlainCSV) Name() string {
+	return "itemID,count,name CSV"
+}
+
+func (PlainCSV) Detect(data []byte) bool {
+	records, err := readCSV(data, 2)
+	if err != nil || len(records) == 0 {
+		return false
+	}
+
+	first := records[0]
+	if isPlainCSVHeader(first) {
+		return true
+	}
+
+	if len(first) < 3 {
+		return false
+	}
+
+	_, err1 := strconv.Atoi(strings.TrimSpace(first[0]))
+	_, err2 := strconv.Atoi(strings.TrimSpace(first[1]))
+
+	return err1 == nil && err2 == nil
+}
+
+func (PlainCSV) Import(data []byte, opts Options) ([]*InventoryData, error) {
+	d := &InventoryData{CharName: opts.CharName}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "character") {
+			d.CharName = strings.TrimSpace(value)
+		}
+	}
+
+	if d.CharName == "" {
+		return nil, errNoCharName
+	}
+
+	records, err := readCSV(data, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, r := range records {
+		if k == 0 && isPlainCSVHeader(r) {
+			continue
+		}
+
+		if len(r) < 3 {
+			return nil, fmt.Errorf("line %d: expected itemID,count,name", k+1)
+		}
+
+		id := strings.TrimSpace(r[0])
+		if _, err := strconv.Atoi(id); err != nil {
+			return nil, fmt.Errorf("line %d: invalid item id %q", k+1, id)
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(r[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid count %q", k+1, r[1])
+		}
+
+		// unquoted names containing commas span several fields
+		name := strings.TrimSpace(strings.Join(r[2:], ","))
+
+		d.add(id, name, database.LocationUnknown, count)
+	}
+
+	return []*InventoryData{d}, nil
+}
+
+func isPlainCSVHeader(r []string) bool {
+	return len(r) == 3 &&
+		strings.EqualFold(strings.TrimSpace(r[0]), "itemID") &&
+		strings.EqualFold(strings.TrimSpace(r[1]), "count") &&
+		strings.EqualFold(strings.TrimSpace(r[2]), "name")
+}
+
+// TSMCSV imports CSV with a header row in the style of TradeSkillMaster
+// exports. An itemString column and a quantity (or count) column are required.
+// Optional itemName, player (or character) and location (or source) columns
+// fill in names, split the rows between characters and place the items. Rows
+// without a player are assigned to Options.CharName. Optional realm and faction
+// columns tell apart characters with the same name.
+type TSMCSV struct{}
+
+func (TSMCSV) Name() string {
+	return "TSM CSV"
+}
+
+func (TSMCSV) Detect(data []byte) bool {
+	records, err := readCSV(data, 1)
+	if err != nil || len(records) == 0 {
+		return false
+	}
+
+	columns := tsmColumns(records[0])
+	_, hasItem := columns["itemstring"]
+	_, hasQuantity := columns["quantity"]
+
+	return hasItem && hasQuantity
+}
+
+func (TSMCSV) Import(data []byte, opts Options) ([]*InventoryData, error) {
+	records, err := readCSV(data, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("no header row")
+	}
+
+	columns := tsmColumns(records[0])
+	field := func(r []string, name string) string {
+		k, ok := columns[name]
+		if !ok || k >= len(r) {
+			return ""
+		}
+
+		return strings.TrimSpace(r[k])
+	}
+
+	byChar := map[string]*InventoryData{}
+	var results []*InventoryData
+
+	for k, r := range records[1:] {
+		line := k + 2
+
+		id := tsmItemID(field(r, "itemstring"))
+		if id == "" {
+			return nil, fmt.Errorf("line %d: invalid item string %q", line, field(r, "itemstring"))
+		}
+
+		count, err := strconv.Atoi(field(r, "quantity"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quantity %q", line, field(r, "quantity"))
+		}
+
+		charName := field(r, "player")
+		if charName == "" {
+			charName = opts.CharName
+		}
+
+		if charName == "" {
+			return nil, fmt.Errorf("line %d: %w", line, errNoCharName)
+		}
+
+		realm := field(r, "realm")
+		key := charName + "-" + strings.ToLower(realm)
+
+		d, ok := byChar[key]
+		if !ok {
+			d = &InventoryData{CharName: charName, Realm: realm, Faction: field(r, "faction")}
+			byChar[key] = d
+			results = append(results, d)
+		}
+
+		d.add(id, field(r, "itemname"), parseLocation(field(r, "location")), count)
+	}
+
+	return results, nil
+}
+
+// tsmColumns maps the lower cased column names of a header row to their index,
+// folding the accepted aliases into one name.
+func tsmColumns(header []string) map[string]int {
+	aliases := map[string]string{
+		"count":     "quantity",
+		"character": "player",
+		"source":    "location",
+		"name":      "itemname",
+	}
+
+	columns := map[string]int{}
+	for k, v := range header {
+		name := strings.ToLower(strings.TrimSpace(v))
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+
+		if _, ok := columns[name]; !ok {
+			columns[name] = k
+		}
+	}
+
+	return columns
+}
+
+var tsmItemStringPattern = regexp.MustCompile(`^(?:i|item)?:?(\d+)`)
+
+// tsmItemID returns the item ID from a TSM item string such as i:12345,
+// item:12345:0:0 or a bare ID.
+func tsmItemID(itemString string) string {
+	m := tsmItemStringPattern.FindStringSubmatch(itemString)
+	if m == nil {
+		return ""
+	}
+
+	return m[1]
+}
+
+// parseLocation maps the location names used by addons to database locations.
+func parseLocation(s string) string {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", "")) {
+	case "bag", "bags", "inventory":
+		return database.LocationBags
+	case "bank":
+		return database.LocationBank
+	case "reagentbank", "reagents":
+		return database.LocationReagentBank
+	case "mail", "mailbox":
+		return database.LocationMail
+	case "equip", "equipped", "equipment":
+		return database.LocationEquipped
+	default:
+		return database.LocationUnknown
+	}
+}
+
+// readCSV reads up to limit records, or all of them if limit is negative,
+// skipping blank and # comment lines.
+func readCSV(data []byte, limit int) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var records [][]string
+	for limit < 0 || len(records) < limit {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
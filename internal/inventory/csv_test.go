@@ -0,0 +1,77 @@
+package inventory_test
+
+import (
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/inventory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlainCSV_Import(t *testing.T) {
+	data := []byte("itemID,count,name\n2589,20,Linen Cloth\n2589,5,Linen Cloth\n\n12359,2,Thorium Bar\n4338,1,Mageweave, Cloth\n")
+
+	require.True(t, inventory.PlainCSV{}.Detect(data))
+	require.False(t, inventory.TSMCSV{}.Detect(data))
+
+	_, err := inventory.PlainCSV{}.Import(data, inventory.Options{})
+	require.ErrorContains(t, err, "character name must be given")
+
+	results, err := inventory.PlainCSV{}.Import(data, inventory.Options{CharName: "Gbank"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	r := results[0]
+	require.Equal(t, "Gbank", r.CharName)
+	require.Equal(t, map[string]int{"2589": 25, "12359": 2, "4338": 1}, r.ItemCounts)
+	require.Equal(t, "Mageweave, Cloth", r.ItemNames["4338"])
+	require.Equal(t, r.ItemCounts, r.Locations()[database.LocationUnknown])
+	require.NoError(t, r.Validate())
+
+	_, err = inventory.PlainCSV{}.Import([]byte("2589,lots,Linen Cloth\n"), inventory.Options{CharName: "Gbank"})
+	require.ErrorContains(t, err, `line 1: invalid count "lots"`)
+}
+
+func TestTSMCSV_Import(t *testing.T) {
+	data := []byte(`itemString,itemName,quantity,player,location
+i:2589,Linen Cloth,20,Gbank,Bags
+item:2589:0:0,Linen Cloth,10,Gbank,Bank
+i:12359,Thorium Bar,4,Gbank,Reagent Bank
+i:2589,Linen Cloth,3,Gbanktwo,Mail
+`)
+
+	require.True(t, inventory.TSMCSV{}.Detect(data))
+	require.False(t, inventory.PlainCSV{}.Detect(data))
+
+	results, err := inventory.TSMCSV{}.Import(data, inventory.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.Equal(t, "Gbank", results[0].CharName)
+	require.Equal(t, map[string]int{"2589": 30, "12359": 4}, results[0].ItemCounts)
+	require.Equal(t, map[string]map[string]int{
+		database.LocationBags:        {"2589": 20},
+		database.LocationBank:        {"2589": 10},
+		database.LocationReagentBank: {"12359": 4},
+	}, results[0].ItemLocations)
+
+	require.Equal(t, "Gbanktwo", results[1].CharName)
+	require.Equal(t, map[string]map[string]int{database.LocationMail: {"2589": 3}}, results[1].ItemLocations)
+
+	results, err = inventory.TSMCSV{}.Import([]byte("itemString,count,name\ni:2589,1,Linen Cloth\n"), inventory.Options{CharName: "Gbank"})
+	require.NoError(t, err)
+	require.Equal(t, "Gbank", results[0].CharName)
+
+	// the realm tells apart characters with the same name
+	results, err = inventory.TSMCSV{}.Import([]byte(`itemString,itemName,quantity,player,realm,faction,location
+i:2589,Linen Cloth,20,Gbank,Mankrik,horde,bags
+i:2589,Linen Cloth,5,Gbank,Pagle,alliance,bags
+`), inventory.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, database.Owner{Name: "Gbank", Realm: "Mankrik", Faction: database.FactionHorde}, results[0].Owner())
+	require.Equal(t, database.Owner{Name: "Gbank", Realm: "Pagle", Faction: database.FactionAlliance}, results[1].Owner())
+
+	_, err = inventory.TSMCSV{}.Import([]byte("itemString,quantity\nbogus,1\n"), inventory.Options{CharName: "Gbank"})
+	require.ErrorContains(t, err, `line 2: invalid item string "bogus"`)
+}
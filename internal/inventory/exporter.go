@@ -0,0 +1,268 @@
+package inventory
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/lua"
+)
+
+// MaxDecodedSize is the most bytes an exporter payload may inflate to, well
+// above what a character's inventory takes but keeping a small compressed
+// upload from using up memory.
+const MaxDecodedSize = 16 << 20
+
+// GringottsExporter imports the base64 encoded, zlib compressed JSON written by
+// the GringottsExporter addon. Several payloads may be given one per line.
+type GringottsExporter struct{}
+
+func (GringottsExporter) Name() string {
+	return "GringottsExporter"
+}
+
+func (GringottsExporter) Detect(data []byte) bool {
+	// the decoded data starts with the two byte zlib header, so three base64
+	// characters are needed
+	data = bytes.TrimSpace(data)
+	if len(data) < 4 {
+		return false
+	}
+
+	header := make([]byte, 3)
+	_, err := base64.StdEncoding.Decode(header, data[:4])
+	if err != nil {
+		return false
+	}
+
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+func (GringottsExporter) Import(data []byte, _ Options) ([]*InventoryData, error) {
+	var results []*InventoryData
+	for _, line := range strings.Fields(string(data)) {
+		r, err := ParseInventoryData(line)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// EncodeInventoryData returns d encoded the way the GringottsExporter addon
+// does, as accepted by ParseInventoryData.
+func EncodeInventoryData(d *InventoryData) (string, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	w := zlib.NewWriter(buf)
+	if _, err := w.Write(b); err != nil {
+		return "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func ParseInventoryData(input string) (*InventoryData, error) {
+	b, err := base64.StdEncoding.DecodeString(input)
+	if err != nil {
+		return nil, err
+	}
+
+	inBuf := bytes.NewBuffer(b)
+
+	r, err := zlib.NewReader(inBuf)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	outBuf := bytes.NewBuffer(nil)
+	n, err := io.Copy(outBuf, io.LimitReader(r, MaxDecodedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if n > MaxDecodedSize {
+		return nil, fmt.Errorf("inventory data is larger than the limit of %d bytes once decompressed", MaxDecodedSize)
+	}
+
+	result, err := decodeInventoryJSON(outBuf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	err = result.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// inventoryDecoders decode each supported payload version.
+var inventoryDecoders = map[int]func([]byte) (*InventoryData, error){
+	VersionLegacy: decodeInventoryV1,
+	Version1:      decodeInventoryV1,
+	Version2:      decodeInventoryV1,
+	Version3:      decodeInventoryV1,
+}
+
+// decodeInventoryJSON decodes a JSON payload of any supported version.
+func decodeInventoryJSON(b []byte) (*InventoryData, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+
+	err := json.Unmarshal(b, &header)
+	if err != nil {
+		return nil, err
+	}
+
+	decode, ok := inventoryDecoders[header.Version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported inventory data version %d, the newest supported version is %d", header.Version, CurrentVersion)
+	}
+
+	return decode(b)
+}
+
+// decodeInventoryV1 decodes the legacy and version 1 to 3 payloads. Version 1
+// added the version field and the later versions only add optional fields.
+func decodeInventoryV1(b []byte) (*InventoryData, error) {
+	var result InventoryData
+	err := json.Unmarshal(b, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GringottsExporterSavedVariables imports the GringottsExporter SavedVariables
+// file, WTF/Account/<account>/SavedVariables/GringottsExporter.lua. Every
+// character found in it is imported, whether stored as a table with the
+// payload fields or as the encoded string the addon displays for copying.
+type GringottsExporterSavedVariables struct{}
+
+var exporterSavedVariablesPattern = regexp.MustCompile(`(?m)^\s*GringottsExporter\w*\s*=`)
+
+func (GringottsExporterSavedVariables) Name() string {
+	return "GringottsExporter SavedVariables"
+}
+
+func (GringottsExporterSavedVariables) Detect(data []byte) bool {
+	return exporterSavedVariablesPattern.Match(data)
+}
+
+func (GringottsExporterSavedVariables) Import(data []byte, _ Options) ([]*InventoryData, error) {
+	vars, err := lua.ParseSavedVariables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var results []*InventoryData
+	for _, name := range names {
+		if !strings.HasPrefix(name, "GringottsExporter") {
+			continue
+		}
+
+		results, err = collectExporterInventories(vars[name], results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// collectExporterInventories walks a SavedVariables value appending every
+// inventory found to results.
+func collectExporterInventories(v any, results []*InventoryData) ([]*InventoryData, error) {
+	switch v := v.(type) {
+	case string:
+		if !(GringottsExporter{}).Detect([]byte(v)) {
+			return results, nil
+		}
+
+		r, err := ParseInventoryData(strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+
+		return append(results, r), nil
+	case *lua.Table:
+		if _, ok := v.Get("charName").(string); ok && v.Table("itemCounts") != nil {
+			b, err := json.Marshal(luaToJSON(v))
+			if err != nil {
+				return nil, err
+			}
+
+			r, err := decodeInventoryJSON(b)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.String("charName"), err)
+			}
+
+			return append(results, r), nil
+		}
+
+		var err error
+		for _, f := range v.Fields() {
+			results, err = collectExporterInventories(f.Value, results)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// luaToJSON converts tables to maps so they can be decoded the same way as the
+// JSON payload. Item IDs are often numeric keys and become strings.
+func luaToJSON(v any) any {
+	t, ok := v.(*lua.Table)
+	if !ok {
+		return v
+	}
+
+	m := make(map[string]any, len(t.Fields()))
+	for _, f := range t.Fields() {
+		var key string
+		switch k := f.Key.(type) {
+		case string:
+			key = k
+		case float64:
+			key = strconv.FormatFloat(k, 'f', -1, 64)
+		default:
+			continue
+		}
+
+		m[key] = luaToJSON(f.Value)
+	}
+
+	return m
+}
@@ -0,0 +1,210 @@
+package inventory_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/inventory"
+	"github.com/stretchr/testify/require"
+)
+
+var inventoryData = "eJxdVcuO47YS/RWiNtl4AL0leze2+zGIPWPYyTRwd7RES4woskFSdhqD/vcURYk3yKabKJNVp+qcOvoFdUf1dzow2MCR6pZpxr4cqew172EF3LJhp0ZpDWx+QZxmRQabNF5BllYVbPBQ5GkEm/UK4ijKY9gkeEqqKJ1+jKs8TmBTrSBJ8qTE2BRM0xw2mbuYFvNjzFz4J3kW4cUkXcE6Wec+ti6j+ZRFWRFi0XSqqgx/THJ8EBUOgSuX5Vg3xxjWquZiVYTgY0xcRmU5oYrTMi99zCHAi6mLZkkRhSKp72m9LvF17jspplhaJVgujhyEFN/myXRa+zaTqCrSkCVboDr0OT4pcx9ydV1vZeEaTnyNtIpdFgzFRTV3XiXr+UVSxfF8iuZTGVXxki5xVd0Q0nSZH7ZezVCq2GdJoiwuAgI3hMx3mft+MTjlKT69CpxGJhHMfcFO0A9yqammV1j0AHtuajVc1XUU1CpNzvQDFo3Ad952ttb0IZg2EBQDZ9aQI23Zg9E7I1vaQtAQHPidy5Y8GcNkzSBICg5U36kgX2veQJAX7JRmRN3Ik2ADc6oNcoOT5sZyiQUErXuy53RQ0r2dW4W9ZnS4KS4gDAL+NwpOJTmOjelH6TZi1ifsRo2wz2Pb4r9X3jhsXrDYT83f2YZ8k3du+JULbj/ISVmuJIRxw7ea1fQdFoXC7x1tWqp/M+St46ZnGgJdcBplbaeCP5VqlCJ7JTzMaTHg0o9CmHcsZJkmO8VdIS9KuIyy1dRMo/DbA+dRsloo283D9kIJuA98QMYbh38UkiHB/2nB7xm8qKvgkpxV3TNLnkfmIPndgldGtXVMPHM90eY3DflEDciJ0un6vHbgSTkoOxpY5AxbZARBYpY37qny+whv3HZMX6nul2bnVYBnFICx5FWpdwi7CluFvD9r2gZReCfDFkTDJLlQaegAy7rBHxrn+6VDVucJ+bVGlIjjwZjDMi0wXDqlrXko3TiYP5lsGZ2F6rceMHdzG/XH/5FOpgdfdU0lzhkraAh+Bq+076nm4ba3Bzg6E6Y47QsXd1wfXBdYDAOeBP+bawdgz1DUBjcTJwyLj+C4JOrCXLEaLCYEL1oN5ipQTxDc5V+ZXtzsUUxv01/PhE86+w+cHZVMjYb80FcIFgnLspDD6Kn3dorDw1oPdcd5bzWtZw/wfgUXixSZmgpGfvBFGA7mSdB2ZA7nAMHJYPYDB/RP2ThssPjxxKlFWtz4ZpeD3aQKvL1lda+c/jaLMv2nAi4PfrPYorGCQfhawEk9mL6NghzVXwqWjw28dArzHUfTaQfs8/Of2zv2BQ=="
+
+func TestParseInventoryData(t *testing.T) {
+	r, err := inventory.ParseInventoryData(inventoryData)
+	require.NoError(t, err)
+
+	// early exporter builds sent the realm as part of the name
+	require.Equal(t, "Margeree-Mankrik", r.CharName)
+	require.Empty(t, r.Realm)
+	require.Len(t, r.ItemCounts, 50)
+	require.Len(t, r.ItemNames, 50)
+	require.Equal(t, 38, r.ItemCounts["13468"])
+	require.Equal(t, "Black Lotus", r.ItemNames["13468"])
+	require.Equal(t, 55, r.ItemCounts["2452"])
+	require.Equal(t, "Swiftthistle", r.ItemNames["2452"])
+}
+
+func TestEncodeInventoryData(t *testing.T) {
+	d := &inventory.InventoryData{
+		Version:       inventory.CurrentVersion,
+		CharName:      "Bankalt",
+		Realm:         "Mankrik",
+		ItemCounts:    map[string]int{"13468": 3},
+		ItemNames:     map[string]string{"13468": "Black Lotus"},
+		ItemLocations: map[string]map[string]int{database.LocationBank: {"13468": 3}},
+	}
+
+	payload, err := inventory.EncodeInventoryData(d)
+	require.NoError(t, err)
+
+	r, err := inventory.ParseInventoryData(payload)
+	require.NoError(t, err)
+	require.Equal(t, d, r)
+
+	// one payload per line, as written by the export command
+	other := *d
+	other.CharName = "Otheralt"
+	otherPayload, err := inventory.EncodeInventoryData(&other)
+	require.NoError(t, err)
+
+	results, err := inventory.Import([]byte(payload+"\n"+otherPayload+"\n"), inventory.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "Otheralt", results[1].CharName)
+}
+
+func TestInventoryData_Locations(t *testing.T) {
+	d := &inventory.InventoryData{
+		CharName:   "testChar",
+		ItemCounts: map[string]int{"1": 3},
+	}
+	require.Equal(t, map[string]map[string]int{database.LocationUnknown: {"1": 3}}, d.Locations())
+
+	d.ItemLocations = map[string]map[string]int{
+		database.LocationBags: {"1": 1},
+		database.LocationBank: {"1": 2},
+	}
+	require.Equal(t, d.ItemLocations, d.Locations())
+}
+
+func encodeInventory(t *testing.T, payload string) string {
+	buf := bytes.NewBuffer(nil)
+	w := zlib.NewWriter(buf)
+	_, err := w.Write([]byte(payload))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestParseInventoryData_Versions(t *testing.T) {
+	r, err := inventory.ParseInventoryData(encodeInventory(t, `{"charName":"Gbank","itemCounts":{"1":2},"itemNames":{"1":"item 1"}}`))
+	require.NoError(t, err)
+	require.Equal(t, inventory.VersionLegacy, r.Version)
+	require.Equal(t, "Gbank", r.CharName)
+
+	r, err = inventory.ParseInventoryData(encodeInventory(t, `{"version":1,"charName":"Gbank","itemCounts":{"1":2},"itemNames":{"1":"item 1"},"itemLocations":{"bags":{"1":2}}}`))
+	require.NoError(t, err)
+	require.Equal(t, inventory.Version1, r.Version)
+	require.Equal(t, map[string]map[string]int{"bags": {"1": 2}}, r.Locations())
+
+	r, err = inventory.ParseInventoryData(encodeInventory(t, `{"version":2,"charName":"Gbank","realm":"Mankrik","faction":"Horde","flavor":"sod","itemCounts":{"1":2},"itemNames":{"1":"item 1"}}`))
+	require.NoError(t, err)
+	require.Equal(t, inventory.Version2, r.Version)
+	require.Equal(t, database.Owner{Name: "Gbank", Realm: "Mankrik", Faction: database.FactionHorde, Flavor: database.FlavorSoD}, r.Owner())
+
+	r, err = inventory.ParseInventoryData(encodeInventory(t, `{"version":3,"charName":"Gbank","itemCounts":{"1":2},"itemNames":{"1":"item 1"},"itemQualities":{"1":4}}`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"1": database.QualityEpic}, r.ItemQualities)
+
+	_, err = inventory.ParseInventoryData(encodeInventory(t, `{"version":3,"charName":"Gbank","itemCounts":{},"itemNames":{},"itemQualities":{"1":9}}`))
+	require.ErrorContains(t, err, "itemQualities[1]: quality 9 is not between 0 and 7")
+
+	_, err = inventory.ParseInventoryData(encodeInventory(t, `{"version":2,"charName":"Gbank","faction":"scourge","flavor":"wotlk","itemCounts":{},"itemNames":{}}`))
+	require.ErrorContains(t, err, `faction: "scourge" is not alliance or horde`)
+	require.ErrorContains(t, err, `flavor: "wotlk" is not one of`)
+
+	_, err = inventory.ParseInventoryData(encodeInventory(t, `{"version":99,"charName":"Gbank"}`))
+	require.ErrorContains(t, err, "unsupported inventory data version 99")
+
+	_, err = inventory.ParseInventoryData(encodeInventory(t, `{"charName":"","itemCounts":{"1":-2},"itemNames":{}}`))
+	var validationErrs inventory.ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	require.Len(t, validationErrs, 3)
+}
+
+func TestParseInventoryData_TooLarge(t *testing.T) {
+	// padding compresses to a few kilobytes but inflates past the limit
+	payload := `{"charName":"Gbank","itemCounts":{},"itemNames":{}}` + strings.Repeat(" ", inventory.MaxDecodedSize)
+	encoded := encodeInventory(t, payload)
+	require.Less(t, len(encoded), 1<<20)
+
+	_, err := inventory.ParseInventoryData(encoded)
+	require.ErrorContains(t, err, "larger than the limit")
+}
+
+func TestInventoryData_Validate(t *testing.T) {
+	d := &inventory.InventoryData{
+		CharName:   "Gbank",
+		ItemCounts: map[string]int{"1": 3, "2": 1},
+		ItemNames:  map[string]string{"1": "item 1", "2": "item 2"},
+		ItemLocations: map[string]map[string]int{
+			database.LocationBags: {"1": 1, "2": 1},
+			database.LocationBank: {"1": 2},
+		},
+	}
+	require.NoError(t, d.Validate())
+
+	d = &inventory.InventoryData{
+		CharName:   " ",
+		ItemCounts: map[string]int{"1": -1, "2": 1, "3": 1},
+		ItemNames:  map[string]string{"1": "item 1", "2": ""},
+		ItemLocations: map[string]map[string]int{
+			"backpack":            {"1": 1},
+			database.LocationBank: {"2": 2, "4": 1},
+		},
+	}
+
+	err := d.Validate()
+	require.Error(t, err)
+
+	var validationErrs inventory.ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	require.Equal(t, inventory.ValidationErrors{
+		{Field: "charName", Message: "is required"},
+		{Field: "itemNames[2]", Message: "name is empty"},
+		{Field: "itemCounts[1]", Message: "count -1 is negative"},
+		{Field: "itemCounts[3]", Message: "item has no entry in itemNames"},
+		{Field: "itemLocations[backpack]", Message: "unknown location"},
+		{Field: "itemLocations[bank][4]", Message: "item has no entry in itemNames"},
+		{Field: "itemCounts[1]", Message: "count -1 does not match the itemLocations total 1"},
+		{Field: "itemCounts[2]", Message: "count 1 does not match the itemLocations total 2"},
+		{Field: "itemCounts[3]", Message: "count 1 does not match the itemLocations total 0"},
+		{Field: "itemLocations[*][4]", Message: "item has no entry in itemCounts"},
+	}, validationErrs)
+	require.Contains(t, err.Error(), "10 problem(s) found")
+}
+
+func TestGringottsExporterSavedVariables_Import(t *testing.T) {
+	data := []byte(`
+GringottsExporterDB = {
+	["Gbank-Whitemane"] = {
+		["version"] = 1,
+		["charName"] = "Gbank",
+		["itemCounts"] = { [2589] = 20, [12359] = 4 },
+		["itemNames"] = { [2589] = "Linen Cloth", [12359] = "Thorium Bar" },
+		["itemLocations"] = {
+			["bags"] = { [2589] = 20 },
+			["bank"] = { [12359] = 4 },
+		},
+	},
+	["Margeree-Mankrik"] = {
+		["export"] = "` + inventoryData + `",
+	},
+}
+`)
+
+	require.True(t, inventory.GringottsExporterSavedVariables{}.Detect(data))
+	require.False(t, inventory.SavedVariables{}.Detect(data))
+
+	results, err := inventory.Import(data, inventory.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.Equal(t, "Gbank", results[0].CharName)
+	require.Equal(t, inventory.Version1, results[0].Version)
+	require.Equal(t, map[string]int{"2589": 20, "12359": 4}, results[0].ItemCounts)
+	require.Equal(t, "Thorium Bar", results[0].ItemNames["12359"])
+	require.Equal(t, map[string]map[string]int{"bags": {"2589": 20}, "bank": {"12359": 4}}, results[0].Locations())
+
+	require.Equal(t, "Margeree-Mankrik", results[1].CharName)
+
+	_, err = inventory.Import([]byte(`GringottsExporterDB = { ["Gbank"] = { ["version"] = 9, ["charName"] = "Gbank", ["itemCounts"] = {} } }`), inventory.Options{})
+	require.ErrorContains(t, err, "Gbank: unsupported inventory data version 9")
+}
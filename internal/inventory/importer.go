@@ -0,0 +1,90 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownFormat is returned when no importer recognizes the data.
+var ErrUnknownFormat = errors.New("unrecognized inventory data format")
+
+// Options are passed to every importer.
+type Options struct {
+	// CharName is used for formats that do not record which character the
+	// inventory belongs to.
+	CharName string
+}
+
+// Importer reads one export format.
+type Importer interface {
+	// Name identifies the format in messages.
+	Name() string
+	// Detect reports whether data looks like this format. It only sniffs the
+	// content, Import may still fail.
+	Detect(data []byte) bool
+	// Import reads every character inventory found in data.
+	Import(data []byte, opts Options) ([]*InventoryData, error)
+}
+
+// Registry holds the importers tried, in order, by Import.
+type Registry struct {
+	importers []Importer
+}
+
+func NewRegistry(importers ...Importer) *Registry {
+	return &Registry{importers: importers}
+}
+
+// Register adds an importer, it is tried after those already registered.
+func (r *Registry) Register(i Importer) {
+	r.importers = append(r.importers, i)
+}
+
+// Import detects the format of data and imports it with the first importer
+// that recognizes it. Every imported inventory is validated.
+func (r *Registry) Import(data []byte, opts Options) ([]*InventoryData, error) {
+	for _, i := range r.importers {
+		if !i.Detect(data) {
+			continue
+		}
+
+		results, err := i.Import(data, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", i.Name(), err)
+		}
+
+		if len(results) == 0 {
+			return nil, fmt.Errorf("%s: no inventories found", i.Name())
+		}
+
+		for _, d := range results {
+			if err := d.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", i.Name(), d.CharName, err)
+			}
+		}
+
+		return results, nil
+	}
+
+	var names []string
+	for _, i := range r.importers {
+		names = append(names, i.Name())
+	}
+
+	return nil, fmt.Errorf("%w, supported formats are %s", ErrUnknownFormat, strings.Join(names, ", "))
+}
+
+// DefaultRegistry holds every built in importer.
+var DefaultRegistry = NewRegistry(
+	GringottsExporter{},
+	GringottsExporterSavedVariables{},
+	SavedVariables{},
+	TSMCSV{},
+	PlainCSV{},
+)
+
+// Import imports data using DefaultRegistry.
+func Import(data []byte, opts Options) ([]*InventoryData, error) {
+	return DefaultRegistry.Import(data, opts)
+}
@@ -0,0 +1,74 @@
+package inventory_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/inventory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport_Detect(t *testing.T) {
+	tests := map[string]struct {
+		data     string
+		expected string
+	}{
+		"exporter":       {data: inventoryData, expected: "Margeree-Mankrik"},
+		"plain csv":      {data: "# character: Gbank\n2589,20,Linen Cloth\n", expected: "Gbank"},
+		"tsm csv":        {data: "itemString,itemName,quantity,player\ni:2589,Linen Cloth,20,Gbank\n", expected: "Gbank"},
+		"saved variable": {data: `BankItems_Save = { ["Gbank|Realm"] = { { link = "|Hitem:2589::|h[Linen Cloth]|h", count = 20 } } }`, expected: "Gbank"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			results, err := inventory.Import([]byte(tt.data), inventory.Options{})
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			require.Equal(t, tt.expected, results[0].CharName)
+		})
+	}
+}
+
+func TestImport_Unknown(t *testing.T) {
+	_, err := inventory.Import([]byte("not an inventory"), inventory.Options{})
+	require.ErrorIs(t, err, inventory.ErrUnknownFormat)
+	require.ErrorContains(t, err, "TSM CSV")
+}
+
+func TestImport_Validates(t *testing.T) {
+	// the name is missing, so the item can not be stored
+	_, err := inventory.Import([]byte("2589,20,\n"), inventory.Options{CharName: "Gbank"})
+
+	var validationErrs inventory.ValidationErrors
+	require.True(t, errors.As(err, &validationErrs))
+	require.ErrorContains(t, err, "itemID,count,name CSV: Gbank")
+}
+
+type fakeImporter struct{}
+
+func (fakeImporter) Name() string {
+	return "fake"
+}
+
+func (fakeImporter) Detect(data []byte) bool {
+	return string(data) == "fake"
+}
+
+func (fakeImporter) Import([]byte, inventory.Options) ([]*inventory.InventoryData, error) {
+	return []*inventory.InventoryData{{
+		CharName:   "Fake",
+		ItemCounts: map[string]int{"1": 1},
+		ItemNames:  map[string]string{"1": "item 1"},
+	}}, nil
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := inventory.NewRegistry()
+	_, err := r.Import([]byte("fake"), inventory.Options{})
+	require.ErrorIs(t, err, inventory.ErrUnknownFormat)
+
+	r.Register(fakeImporter{})
+	results, err := r.Import([]byte("fake"), inventory.Options{})
+	require.NoError(t, err)
+	require.Equal(t, "Fake", results[0].CharName)
+}
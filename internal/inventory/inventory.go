@@ -0,0 +1,85 @@
+// Package inventory reads bank character inventories exported by WoW addons
+// into InventoryData.
+package inventory
+
+import (
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// Versions of the GringottsExporter payload. Builds before versioning was
+// added send no version field and decode as VersionLegacy.
+const (
+	VersionLegacy = 0
+	Version1      = 1
+	// Version2 adds the realm, faction and game flavor of the character.
+	Version2 = 2
+	// Version3 adds item qualities.
+	Version3 = 3
+
+	// CurrentVersion is the newest payload version understood.
+	CurrentVersion = Version3
+)
+
+type InventoryData struct {
+	Version  int    `json:"version,omitempty"`
+	CharName string `json:"charName"`
+	// Realm, Faction and Flavor identify the character beyond its name. Older
+	// exporter builds do not send them.
+	Realm      string            `json:"realm,omitempty"`
+	Faction    string            `json:"faction,omitempty"`
+	Flavor     string            `json:"flavor,omitempty"`
+	ItemCounts map[string]int    `json:"itemCounts"`
+	ItemNames  map[string]string `json:"itemNames"`
+	// ItemQualities maps item IDs to their quality. It is optional, even in
+	// the versions that send it.
+	ItemQualities map[string]int `json:"itemQualities,omitempty"`
+	// ItemLocations maps a location (bags, bank, reagentBank, mail, equipped)
+	// to the item counts held there. Older exporter builds do not send it.
+	ItemLocations map[string]map[string]int `json:"itemLocations,omitempty"`
+}
+
+// Owner returns the bank character holding the inventory.
+func (d *InventoryData) Owner() database.Owner {
+	return database.Owner{
+		Name:    d.CharName,
+		Realm:   d.Realm,
+		Faction: strings.ToLower(d.Faction),
+		Flavor:  strings.ToLower(d.Flavor),
+	}
+}
+
+// Locations returns the item counts broken down by location. When the payload
+// carries no location data the totals are reported under an unknown location.
+func (d *InventoryData) Locations() map[string]map[string]int {
+	if len(d.ItemLocations) > 0 {
+		return d.ItemLocations
+	}
+
+	return map[string]map[string]int{database.LocationUnknown: d.ItemCounts}
+}
+
+// add records count of an item held in location, keeping the totals in
+// ItemCounts in step. It is used by importers that build the data up item by
+// item.
+func (d *InventoryData) add(id, name, location string, count int) {
+	if d.ItemCounts == nil {
+		d.ItemCounts = map[string]int{}
+	}
+	if d.ItemNames == nil {
+		d.ItemNames = map[string]string{}
+	}
+	if d.ItemLocations == nil {
+		d.ItemLocations = map[string]map[string]int{}
+	}
+	if d.ItemLocations[location] == nil {
+		d.ItemLocations[location] = map[string]int{}
+	}
+
+	d.ItemCounts[id] += count
+	d.ItemLocations[location][id] += count
+	if name != "" {
+		d.ItemNames[id] = name
+	}
+}
@@ -0,0 +1,236 @@
+package inventory
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/lua"
+)
+
+// SavedVariables imports the SavedVariables files of bank addons that store
+// item links per character: BankItems (BankItems_Save) and Bagnon
+// (BrotherBags). Items stored without a link carrying the name can not be
+// named and fail validation.
+type SavedVariables struct{}
+
+var savedVariablesPattern = regexp.MustCompile(`(?m)^\s*(BankItems_Save|BrotherBags)\s*=`)
+
+func (SavedVariables) Name() string {
+	return "BankItems/Bagnon SavedVariables"
+}
+
+func (SavedVariables) Detect(data []byte) bool {
+	return savedVariablesPattern.Match(data)
+}
+
+func (SavedVariables) Import(data []byte, _ Options) ([]*InventoryData, error) {
+	vars, err := lua.ParseSavedVariables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*InventoryData
+
+	if save, ok := vars["BankItems_Save"].(*lua.Table); ok {
+		for _, f := range save.Fields() {
+			key, _ := f.Key.(string)
+			char, ok := f.Value.(*lua.Table)
+			if !ok || key == "" {
+				continue
+			}
+
+			results = append(results, importBankItems(key, char))
+		}
+	}
+
+	if brother, ok := vars["BrotherBags"].(*lua.Table); ok {
+		for _, realm := range brother.Fields() {
+			chars, ok := realm.Value.(*lua.Table)
+			if !ok {
+				continue
+			}
+
+			realmName, _ := realm.Key.(string)
+
+			for _, f := range chars.Fields() {
+				name, _ := f.Key.(string)
+				char, ok := f.Value.(*lua.Table)
+				if !ok || name == "" {
+					continue
+				}
+
+				d := importBagnon(name, char)
+				d.Realm = strings.TrimSpace(realmName)
+				results = append(results, d)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// importBankItems reads a BankItems_Save character, keyed "Name|Realm". Bank
+// slots are stored in the list part of the table and containers under BagN.
+func importBankItems(key string, char *lua.Table) *InventoryData {
+	name, realm, _ := strings.Cut(key, "|")
+	d := &InventoryData{CharName: strings.TrimSpace(name), Realm: strings.TrimSpace(realm)}
+
+	for _, f := range char.Fields() {
+		switch k := f.Key.(type) {
+		case float64:
+			addLinkTable(d, f.Value, database.LocationBank)
+		case string:
+			bag, ok := strings.CutPrefix(k, "Bag")
+			if !ok {
+				continue
+			}
+
+			id, err := strconv.Atoi(bag)
+			if err != nil {
+				continue
+			}
+
+			contents, ok := f.Value.(*lua.Table)
+			if !ok {
+				continue
+			}
+
+			location := bankItemsBagLocation(id)
+			for _, slot := range contents.Fields() {
+				// the string keyed fields describe the container itself
+				if _, ok := slot.Key.(float64); ok {
+					addLinkTable(d, slot.Value, location)
+				}
+			}
+		}
+	}
+
+	return d
+}
+
+// bankItemsBagLocation maps BankItems container numbers to locations.
+func bankItemsBagLocation(id int) string {
+	switch {
+	case id >= 0 && id <= 4:
+		return database.LocationBags
+	case id >= 5 && id <= 11, id == 100:
+		return database.LocationBank
+	case id == 101:
+		return database.LocationEquipped
+	case id == 103:
+		return database.LocationMail
+	case id == -3:
+		return database.LocationReagentBank
+	default:
+		return database.LocationUnknown
+	}
+}
+
+// addLinkTable adds an item stored as { link = "...", count = n }.
+func addLinkTable(d *InventoryData, v any, location string) {
+	item, ok := v.(*lua.Table)
+	if !ok {
+		return
+	}
+
+	count := int(item.Number("count"))
+	if count == 0 {
+		count = 1
+	}
+
+	addLink(d, item.String("link"), location, count)
+}
+
+// importBagnon reads a BrotherBags character. Containers are keyed by bag ID
+// and hold "link;count" strings.
+func importBagnon(name string, char *lua.Table) *InventoryData {
+	d := &InventoryData{CharName: strings.TrimSpace(name), Faction: strings.ToLower(char.String("faction"))}
+
+	for _, f := range char.Fields() {
+		var location string
+		switch k := f.Key.(type) {
+		case float64:
+			location = bagnonBagLocation(int(k))
+		case string:
+			if k != "equip" {
+				continue
+			}
+			location = database.LocationEquipped
+		}
+
+		contents, ok := f.Value.(*lua.Table)
+		if !ok {
+			continue
+		}
+
+		for _, slot := range contents.Fields() {
+			if _, ok := slot.Key.(float64); !ok {
+				continue
+			}
+
+			entry, _ := slot.Value.(string)
+			link, countStr, _ := strings.Cut(entry, ";")
+			count, err := strconv.Atoi(countStr)
+			if err != nil || count == 0 {
+				count = 1
+			}
+
+			addLink(d, link, location, count)
+		}
+	}
+
+	return d
+}
+
+// bagnonBagLocation maps Bagnon bag IDs to locations.
+func bagnonBagLocation(id int) string {
+	switch {
+	case id >= 0 && id <= 4:
+		return database.LocationBags
+	case id == -1, id >= 5 && id <= 12:
+		return database.LocationBank
+	case id == -3:
+		return database.LocationReagentBank
+	default:
+		return database.LocationUnknown
+	}
+}
+
+var (
+	linkIDPattern   = regexp.MustCompile(`(?:^|item:)(\d+)`)
+	linkNamePattern = regexp.MustCompile(`\|h\[(.*?)\]\|h`)
+	// linkColorPattern matches the ARGB color of a link, which tells the
+	// item quality.
+	linkColorPattern = regexp.MustCompile(`^\|c[0-9a-fA-F]{2}([0-9a-fA-F]{6})`)
+)
+
+// addLink adds count of the item in an item link or bare item string.
+func addLink(d *InventoryData, link, location string, count int) {
+	m := linkIDPattern.FindStringSubmatch(link)
+	if m == nil {
+		return
+	}
+
+	var name string
+	if n := linkNamePattern.FindStringSubmatch(link); n != nil {
+		name = n[1]
+	}
+
+	d.add(m[1], name, location, count)
+
+	if c := linkColorPattern.FindStringSubmatch(link); c != nil {
+		rgb, _ := strconv.ParseInt(c[1], 16, 32)
+		for q := database.QualityPoor; q <= database.QualityHeirloom; q++ {
+			if database.QualityColor(q) != int(rgb) {
+				continue
+			}
+
+			if d.ItemQualities == nil {
+				d.ItemQualities = map[string]int{}
+			}
+			d.ItemQualities[m[1]] = q
+		}
+	}
+}
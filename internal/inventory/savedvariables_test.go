@@ -0,0 +1,89 @@
+package inventory_test
+
+import (
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/inventory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedVariables_BankItems(t *testing.T) {
+	data := []byte(`
+BankItems_Save = {
+	["Gbank|Whitemane"] = {
+		{ ["link"] = "|cffffffff|Hitem:2589::::::::60:::::::|h[Linen Cloth]|h|r", ["count"] = 20 },
+		["Bag0"] = {
+			{ ["link"] = "|cffffffff|Hitem:12359::::::::60:::::::|h[Thorium Bar]|h|r", ["count"] = 4 },
+			["size"] = 16,
+		},
+		["Bag5"] = {
+			["link"] = "|cffffffff|Hitem:4500::::::::60:::::::|h[Traveler's Backpack]|h|r",
+			{ ["link"] = "|cffffffff|Hitem:2589::::::::60:::::::|h[Linen Cloth]|h|r", ["count"] = 5 },
+		},
+		["Bag101"] = {
+			{ ["link"] = "|cffa335ee|Hitem:19019::::::::60:::::::|h[Thunderfury]|h|r" },
+		},
+		["money"] = 1000,
+	},
+}
+`)
+
+	require.True(t, inventory.SavedVariables{}.Detect(data))
+
+	results, err := inventory.SavedVariables{}.Import(data, inventory.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	r := results[0]
+	require.Equal(t, "Gbank", r.CharName)
+	require.Equal(t, "Whitemane", r.Realm)
+	require.Equal(t, map[string]int{"2589": 25, "12359": 4, "19019": 1}, r.ItemCounts)
+	require.Equal(t, map[string]map[string]int{
+		database.LocationBank:     {"2589": 25},
+		database.LocationBags:     {"12359": 4},
+		database.LocationEquipped: {"19019": 1},
+	}, r.ItemLocations)
+	require.Equal(t, "Thunderfury", r.ItemNames["19019"])
+	// link colors tell the quality
+	require.Equal(t, map[string]int{"2589": database.QualityCommon, "12359": database.QualityCommon, "19019": database.QualityEpic}, r.ItemQualities)
+	require.NoError(t, r.Validate())
+}
+
+func TestSavedVariables_Bagnon(t *testing.T) {
+	data := []byte(`
+BrotherBags = {
+	["Whitemane"] = {
+		["Gbank"] = {
+			[0] = { "|cffffffff|Hitem:2589::::::::60:::::::|h[Linen Cloth]|h|r;20", ["size"] = 16 },
+			[-1] = { "|cffffffff|Hitem:12359::::::::60:::::::|h[Thorium Bar]|h|r;4" },
+			["equip"] = { "|cffa335ee|Hitem:19019::::::::60:::::::|h[Thunderfury]|h|r" },
+			["faction"] = "Alliance",
+		},
+		["Gbanktwo"] = {
+			[0] = { "12359:0:0;2" },
+		},
+	},
+}
+`)
+
+	require.True(t, inventory.SavedVariables{}.Detect(data))
+
+	results, err := inventory.SavedVariables{}.Import(data, inventory.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.Equal(t, "Gbank", results[0].CharName)
+	require.Equal(t, database.Owner{Name: "Gbank", Realm: "Whitemane", Faction: database.FactionAlliance}, results[0].Owner())
+	require.Equal(t, map[string]map[string]int{
+		database.LocationBags:     {"2589": 20},
+		database.LocationBank:     {"12359": 4},
+		database.LocationEquipped: {"19019": 1},
+	}, results[0].ItemLocations)
+	require.NoError(t, results[0].Validate())
+
+	// bare item strings carry no name
+	require.Equal(t, "Gbanktwo", results[1].CharName)
+	require.Equal(t, map[string]int{"12359": 2}, results[1].ItemCounts)
+	require.Error(t, results[1].Validate())
+}
@@ -0,0 +1,151 @@
+package inventory
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// maxReportedValidationErrors is the most problems listed in the message of
+// ValidationErrors, the full list is still available to callers.
+const maxReportedValidationErrors = 20
+
+// knownLocations are the locations accepted in InventoryData.ItemLocations.
+var knownLocations = map[string]bool{
+	database.LocationUnknown:     true,
+	database.LocationBags:        true,
+	database.LocationBank:        true,
+	database.LocationReagentBank: true,
+	database.LocationMail:        true,
+	database.LocationEquipped:    true,
+}
+
+// ValidationError is a single problem found in an inventory payload.
+type ValidationError struct {
+	// Field is the path of the offending value, such as itemCounts[1234].
+	Field   string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors is every problem found in an inventory payload.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf("invalid inventory data, %d problem(s) found:", len(e)))
+	for k, v := range e {
+		if k == maxReportedValidationErrors {
+			b.WriteString(fmt.Sprintf("\n… and %d more", len(e)-k))
+			break
+		}
+
+		b.WriteString("\n")
+		b.WriteString(v.Error())
+	}
+
+	return b.String()
+}
+
+// Validate checks the payload for missing and inconsistent data, returning
+// ValidationErrors listing every problem or nil if there are none.
+func (d *InventoryData) Validate() error {
+	var errs ValidationErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(d.CharName) == "" {
+		add("charName", "is required")
+	}
+
+	switch strings.ToLower(d.Faction) {
+	case "", database.FactionAlliance, database.FactionHorde:
+	default:
+		add("faction", "%q is not alliance or horde", d.Faction)
+	}
+
+	if d.Flavor != "" && !slices.Contains(database.Flavors, strings.ToLower(d.Flavor)) {
+		add("flavor", "%q is not one of %s", d.Flavor, strings.Join(database.Flavors, ", "))
+	}
+
+	for _, id := range sortedKeys(d.ItemNames) {
+		if strings.TrimSpace(d.ItemNames[id]) == "" {
+			add(fmt.Sprintf("itemNames[%s]", id), "name is empty")
+		}
+	}
+
+	for _, id := range sortedKeys(d.ItemQualities) {
+		if q := d.ItemQualities[id]; q < database.QualityPoor || q > database.QualityHeirloom {
+			add(fmt.Sprintf("itemQualities[%s]", id), "quality %d is not between %d and %d", q, database.QualityPoor, database.QualityHeirloom)
+		}
+	}
+
+	for _, id := range sortedKeys(d.ItemCounts) {
+		field := fmt.Sprintf("itemCounts[%s]", id)
+		if id == "" {
+			add(field, "item id is empty")
+		}
+		if d.ItemCounts[id] < 0 {
+			add(field, "count %d is negative", d.ItemCounts[id])
+		}
+		if _, ok := d.ItemNames[id]; !ok {
+			add(field, "item has no entry in itemNames")
+		}
+	}
+
+	totals := map[string]int{}
+	for _, location := range sortedKeys(d.ItemLocations) {
+		if !knownLocations[location] {
+			add(fmt.Sprintf("itemLocations[%s]", location), "unknown location")
+		}
+
+		counts := d.ItemLocations[location]
+		for _, id := range sortedKeys(counts) {
+			field := fmt.Sprintf("itemLocations[%s][%s]", location, id)
+			if counts[id] < 0 {
+				add(field, "count %d is negative", counts[id])
+			}
+			if _, ok := d.ItemNames[id]; !ok {
+				add(field, "item has no entry in itemNames")
+			}
+			totals[id] += counts[id]
+		}
+	}
+
+	if len(d.ItemLocations) > 0 {
+		for _, id := range sortedKeys(d.ItemCounts) {
+			if totals[id] != d.ItemCounts[id] {
+				add(fmt.Sprintf("itemCounts[%s]", id), "count %d does not match the itemLocations total %d", d.ItemCounts[id], totals[id])
+			}
+		}
+		for _, id := range sortedKeys(totals) {
+			if _, ok := d.ItemCounts[id]; !ok {
+				add(fmt.Sprintf("itemLocations[*][%s]", id), "item has no entry in itemCounts")
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
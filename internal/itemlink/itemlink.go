@@ -0,0 +1,116 @@
+// Package itemlink builds links to item pages on database sites for the game
+// flavor a bank plays.
+package itemlink
+
+import (
+	"fmt"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// Sites item links can point to.
+const (
+	SiteWowhead = "wowhead"
+	// SiteClassicDB is classicdb.ch, which only has the original classic
+	// items.
+	SiteClassicDB = "classicdb"
+)
+
+// Sites lists every site.
+var Sites = []string{SiteWowhead, SiteClassicDB}
+
+// sites build the URL of an item page for a flavor, reporting false when the
+// site has no pages for it.
+var sites = map[string]func(flavor, id string) (string, bool){
+	SiteWowhead:   wowheadURL,
+	SiteClassicDB: classicDBURL,
+}
+
+func wowheadURL(flavor, id string) (string, bool) {
+	switch flavor {
+	case database.FlavorRetail:
+		return fmt.Sprintf("https://www.wowhead.com/item=%s", id), true
+	case database.FlavorCata:
+		return fmt.Sprintf("https://www.wowhead.com/cata/item=%s", id), true
+	default:
+		// season of discovery and hardcore share the classic database
+		return fmt.Sprintf("https://www.wowhead.com/classic/item=%s", id), true
+	}
+}
+
+func classicDBURL(flavor, id string) (string, bool) {
+	switch flavor {
+	case database.FlavorClassic, database.FlavorHardcore:
+		return fmt.Sprintf("https://classicdb.ch/?item=%s", id), true
+	default:
+		return "", false
+	}
+}
+
+// Linker links items to the configured site, falling back to wowhead for
+// flavors the site does not cover.
+type Linker struct {
+	site   string
+	flavor string
+	// guildFlavors overrides flavor for single guilds.
+	guildFlavors map[string]string
+}
+
+// New returns a Linker for site, with flavor used for guilds missing from
+// guildFlavors.
+func New(site, flavor string, guildFlavors map[string]string) *Linker {
+	return &Linker{site: site, flavor: flavor, guildFlavors: guildFlavors}
+}
+
+// Flavor returns the flavor of items held by owners in guildID. When every
+// owner with a known flavor plays the same one it is used, otherwise the
+// flavor of the guild.
+func (l *Linker) Flavor(guildID string, owners ...database.Owner) string {
+	var flavor string
+	for _, o := range owners {
+		if o.Flavor == "" {
+			continue
+		}
+
+		if flavor != "" && flavor != o.Flavor {
+			flavor = ""
+			break
+		}
+
+		flavor = o.Flavor
+	}
+
+	if flavor != "" {
+		return flavor
+	}
+
+	if f, ok := l.guildFlavors[guildID]; ok {
+		return f
+	}
+
+	return l.flavor
+}
+
+// URL returns the page of item id for flavor and the name of the site it is on.
+func (l *Linker) URL(flavor, id string) (string, string) {
+	if build, ok := sites[l.site]; ok {
+		if url, ok := build(flavor, id); ok {
+			return url, l.site
+		}
+	}
+
+	url, _ := wowheadURL(flavor, id)
+
+	return url, SiteWowhead
+}
+
+// Markdown links name to the page of item id for flavor. An empty name is
+// replaced by the name of the site.
+func (l *Linker) Markdown(name, id, flavor string) string {
+	url, site := l.URL(flavor, id)
+	if name == "" {
+		name = site
+	}
+
+	return fmt.Sprintf("[%s](%s)", name, url)
+}
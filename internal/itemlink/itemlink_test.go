@@ -0,0 +1,54 @@
+package itemlink_test
+
+import (
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/database"
+	"github.com/jbweber/gringotts-bot/internal/itemlink"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinker_URL(t *testing.T) {
+	l := itemlink.New(itemlink.SiteWowhead, database.FlavorClassic, nil)
+
+	for flavor, want := range map[string]string{
+		database.FlavorClassic:  "https://www.wowhead.com/classic/item=19019",
+		database.FlavorSoD:      "https://www.wowhead.com/classic/item=19019",
+		database.FlavorHardcore: "https://www.wowhead.com/classic/item=19019",
+		database.FlavorCata:     "https://www.wowhead.com/cata/item=19019",
+		database.FlavorRetail:   "https://www.wowhead.com/item=19019",
+	} {
+		url, site := l.URL(flavor, "19019")
+		require.Equal(t, want, url, flavor)
+		require.Equal(t, itemlink.SiteWowhead, site)
+	}
+
+	l = itemlink.New(itemlink.SiteClassicDB, database.FlavorClassic, nil)
+
+	url, site := l.URL(database.FlavorHardcore, "19019")
+	require.Equal(t, "https://classicdb.ch/?item=19019", url)
+	require.Equal(t, itemlink.SiteClassicDB, site)
+
+	// flavors the site does not cover fall back to wowhead
+	url, site = l.URL(database.FlavorCata, "19019")
+	require.Equal(t, "https://www.wowhead.com/cata/item=19019", url)
+	require.Equal(t, itemlink.SiteWowhead, site)
+
+	require.Equal(t, "[classicdb](https://classicdb.ch/?item=2589)", l.Markdown("", "2589", database.FlavorClassic))
+	require.Equal(t, "[Linen Cloth](https://www.wowhead.com/item=2589)", l.Markdown("Linen Cloth", "2589", database.FlavorRetail))
+}
+
+func TestLinker_Flavor(t *testing.T) {
+	l := itemlink.New(itemlink.SiteWowhead, database.FlavorClassic, map[string]string{"2": database.FlavorCata})
+
+	require.Equal(t, database.FlavorClassic, l.Flavor("1"))
+	require.Equal(t, database.FlavorCata, l.Flavor("2"))
+
+	sod := database.Owner{Name: "Gbank", Flavor: database.FlavorSoD}
+	hardcore := database.Owner{Name: "Gbanktwo", Flavor: database.FlavorHardcore}
+	unknown := database.Owner{Name: "Gbankthree"}
+
+	require.Equal(t, database.FlavorSoD, l.Flavor("2", sod, unknown))
+	require.Equal(t, database.FlavorCata, l.Flavor("2", sod, hardcore, sod))
+	require.Equal(t, database.FlavorClassic, l.Flavor("1", unknown))
+}
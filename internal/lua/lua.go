@@ -0,0 +1,613 @@
+// Package lua parses the data-only subset of Lua written by World of Warcraft
+// to SavedVariables files: assignments of tables, strings, numbers, booleans
+// and nil. Nothing is executed, any other construct is a syntax error.
+package lua
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Field is a single key and value of a Table.
+type Field struct {
+	Key   any
+	Value any
+}
+
+// Table is a Lua table. Keys and values are nil, bool, float64, string or
+// *Table. Integer keys, including the implicit keys of list entries, are
+// float64 so {"a"} and {[1] = "a"} are equal.
+type Table struct {
+	fields []Field
+	index  map[any]int
+	// removed counts the fields emptied by setting them to nil, which are
+	// left in place until compact drops them.
+	removed int
+}
+
+// NewTable returns an empty table.
+func NewTable() *Table {
+	return &Table{index: map[any]int{}}
+}
+
+// Set assigns value to key, replacing any existing value. Setting a key to nil
+// removes it, as in Lua.
+func (t *Table) Set(key, value any) {
+	if k, ok := t.index[key]; ok {
+		if value == nil {
+			// emptied fields hold a nil value, compacting once half of them
+			// are empty keeps removing cheap
+			t.fields[k] = Field{}
+			delete(t.index, key)
+			t.removed++
+			if t.removed > len(t.fields)/2 {
+				t.compact()
+			}
+			return
+		}
+
+		t.fields[k].Value = value
+		return
+	}
+
+	if value == nil {
+		return
+	}
+
+	t.index[key] = len(t.fields)
+	t.fields = append(t.fields, Field{Key: key, Value: value})
+}
+
+// compact drops the emptied fields, keeping the others in order.
+func (t *Table) compact() {
+	if t.removed == 0 {
+		return
+	}
+
+	fields := t.fields[:0]
+	for _, f := range t.fields {
+		if f.Value != nil {
+			t.index[f.Key] = len(fields)
+			fields = append(fields, f)
+		}
+	}
+
+	clear(t.fields[len(fields):])
+	t.fields = fields
+	t.removed = 0
+}
+
+// Get returns the value stored under key or nil. Integer keys may be passed as
+// int.
+func (t *Table) Get(key any) any {
+	if i, ok := key.(int); ok {
+		key = float64(i)
+	}
+
+	if k, ok := t.index[key]; ok {
+		return t.fields[k].Value
+	}
+
+	return nil
+}
+
+// String returns the string stored under key, or "" if it is not a string.
+func (t *Table) String(key any) string {
+	s, _ := t.Get(key).(string)
+	return s
+}
+
+// Number returns the number stored under key, or 0 if it is not a number.
+func (t *Table) Number(key any) float64 {
+	n, _ := t.Get(key).(float64)
+	return n
+}
+
+// Table returns the table stored under key, or nil if it is not a table.
+func (t *Table) Table(key any) *Table {
+	v, _ := t.Get(key).(*Table)
+	return v
+}
+
+// Fields returns every field in the order it appeared in the source.
+func (t *Table) Fields() []Field {
+	t.compact()
+	return t.fields
+}
+
+// Len returns the length of the list part of the table, the number of
+// consecutive integer keys starting from 1.
+func (t *Table) Len() int {
+	n := 0
+	for t.Get(n+1) != nil {
+		n++
+	}
+
+	return n
+}
+
+// SyntaxError reports the position of invalid input.
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("lua: line %d column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ParseSavedVariables parses a sequence of global assignments, as found in a
+// SavedVariables file, returning the assigned values by name.
+func ParseSavedVariables(data []byte) (map[string]any, error) {
+	p := &parser{src: string(data), line: 1, lineStart: 0}
+
+	vars := map[string]any{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return vars, nil
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if !p.consume('=') {
+			return nil, p.errorf("expected = after %s", name)
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		vars[name] = v
+
+		p.skipSpace()
+		p.consume(';')
+	}
+}
+
+// ParseValue parses a single Lua value, such as a table constructor.
+func ParseValue(data []byte) (any, error) {
+	p := &parser{src: string(data), line: 1, lineStart: 0}
+
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q after value", p.peek())
+	}
+
+	return v, nil
+}
+
+// maxDepth bounds table nesting so hostile input cannot exhaust the stack.
+const maxDepth = 100
+
+type parser struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+	depth     int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: p.line, Column: p.pos - p.lineStart + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+
+	return p.src[p.pos]
+}
+
+func (p *parser) advance() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+		p.lineStart = p.pos
+	}
+
+	return c
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.peek() == c && !p.eof() {
+		p.advance()
+		return true
+	}
+
+	return false
+}
+
+// skipSpace skips whitespace and comments.
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == '\v':
+			p.advance()
+		case strings.HasPrefix(p.src[p.pos:], "--"):
+			p.pos += 2
+			if level, ok := p.longBracketLevel(); ok {
+				// a malformed long comment runs to the end of input
+				_, _ = p.longString(level)
+				continue
+			}
+
+			for !p.eof() && p.peek() != '\n' {
+				p.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (p *parser) name() (string, error) {
+	if !isNameStart(p.peek()) {
+		return "", p.errorf("expected a name, found %q", p.peek())
+	}
+
+	start := p.pos
+	for !p.eof() && (isNameStart(p.peek()) || isDigit(p.peek())) {
+		p.advance()
+	}
+
+	return p.src[start:p.pos], nil
+}
+
+func (p *parser) value() (any, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unexpected end of input, expected a value")
+	}
+
+	switch c := p.peek(); {
+	case c == '{':
+		return p.table()
+	case c == '"' || c == '\'':
+		return p.quotedString()
+	case c == '[':
+		level, ok := p.longBracketLevel()
+		if !ok {
+			return nil, p.errorf("invalid long string")
+		}
+		return p.longString(level)
+	case c == '-':
+		// repeated negation recurses like nested tables do
+		p.depth++
+		defer func() { p.depth-- }()
+
+		if p.depth > maxDepth {
+			return nil, p.errorf("values nested deeper than %d", maxDepth)
+		}
+
+		p.advance()
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		n, ok := v.(float64)
+		if !ok {
+			return nil, p.errorf("cannot negate a non number")
+		}
+
+		return -n, nil
+	case isDigit(c) || c == '.':
+		return p.number()
+	case isNameStart(c):
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "nil":
+			return nil, nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		default:
+			return nil, p.errorf("unsupported expression %s", name)
+		}
+	default:
+		return nil, p.errorf("unexpected %q, expected a value", c)
+	}
+}
+
+func (p *parser) table() (*Table, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+
+	if p.depth > maxDepth {
+		return nil, p.errorf("tables nested deeper than %d", maxDepth)
+	}
+
+	p.advance() // {
+	t := NewTable()
+	next := 1.0
+
+	for {
+		p.skipSpace()
+		if p.consume('}') {
+			return t, nil
+		}
+
+		var key, value any
+		var err error
+
+		switch {
+		case p.peek() == '[' && !strings.HasPrefix(p.src[p.pos:], "[[") && !strings.HasPrefix(p.src[p.pos:], "[="):
+			p.advance()
+			key, err = p.value()
+			if err != nil {
+				return nil, err
+			}
+
+			p.skipSpace()
+			if !p.consume(']') {
+				return nil, p.errorf("expected ] after table key")
+			}
+
+			p.skipSpace()
+			if !p.consume('=') {
+				return nil, p.errorf("expected = after table key")
+			}
+
+			value, err = p.value()
+		case isNameStart(p.peek()) && p.isNamedField():
+			key, _ = p.name()
+			p.skipSpace()
+			p.advance() // =
+			value, err = p.value()
+		default:
+			key = next
+			next++
+			value, err = p.value()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch k := key.(type) {
+		case nil:
+			return nil, p.errorf("table key is nil")
+		case float64:
+			if math.IsNaN(k) {
+				return nil, p.errorf("table key is NaN")
+			}
+		}
+
+		t.Set(key, value)
+
+		p.skipSpace()
+		if p.consume(',') || p.consume(';') {
+			continue
+		}
+
+		if p.consume('}') {
+			return t, nil
+		}
+
+		return nil, p.errorf("expected , or } in table, found %q", p.peek())
+	}
+}
+
+// isNamedField reports whether the input is a name = value field rather than a
+// value such as true or nil.
+func (p *parser) isNamedField() bool {
+	save, line, lineStart := p.pos, p.line, p.lineStart
+	defer func() { p.pos, p.line, p.lineStart = save, line, lineStart }()
+
+	if _, err := p.name(); err != nil {
+		return false
+	}
+
+	p.skipSpace()
+
+	return p.peek() == '=' && !strings.HasPrefix(p.src[p.pos:], "==")
+}
+
+func (p *parser) number() (float64, error) {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], "0x") || strings.HasPrefix(p.src[p.pos:], "0X") {
+		p.pos += 2
+		for !p.eof() && strings.IndexByte("0123456789abcdefABCDEF", p.peek()) >= 0 {
+			p.advance()
+		}
+
+		n, err := strconv.ParseUint(p.src[start+2:p.pos], 16, 64)
+		if err != nil {
+			return 0, p.errorf("invalid number %s", p.src[start:p.pos])
+		}
+
+		return float64(n), nil
+	}
+
+	for !p.eof() {
+		c := p.peek()
+		if isDigit(c) || c == '.' {
+			p.advance()
+			continue
+		}
+
+		if c == 'e' || c == 'E' {
+			p.advance()
+			if p.peek() == '+' || p.peek() == '-' {
+				p.advance()
+			}
+			continue
+		}
+
+		break
+	}
+
+	text := p.src[start:p.pos]
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, p.errorf("invalid number %s", text)
+	}
+
+	return n, nil
+}
+
+func (p *parser) quotedString() (string, error) {
+	quote := p.advance()
+	b := strings.Builder{}
+
+	for {
+		if p.eof() {
+			return "", p.errorf("unfinished string")
+		}
+
+		c := p.advance()
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\n':
+			return "", p.errorf("unfinished string")
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *parser) escape(b *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("unfinished string")
+	}
+
+	c := p.advance()
+	switch c {
+	case 'a':
+		b.WriteByte('\a')
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'v':
+		b.WriteByte('\v')
+	case '\\', '"', '\'', '\n':
+		b.WriteByte(c)
+	case 'x':
+		if p.pos+2 > len(p.src) {
+			return p.errorf("invalid hex escape")
+		}
+
+		n, err := strconv.ParseUint(p.src[p.pos:p.pos+2], 16, 8)
+		if err != nil {
+			return p.errorf("invalid hex escape")
+		}
+
+		p.pos += 2
+		b.WriteByte(byte(n))
+	case 'z':
+		for !p.eof() && strings.IndexByte(" \t\r\n\f\v", p.peek()) >= 0 {
+			p.advance()
+		}
+	default:
+		if !isDigit(c) {
+			return p.errorf("invalid escape \\%c", c)
+		}
+
+		start := p.pos - 1
+		for p.pos-start < 3 && !p.eof() && isDigit(p.peek()) {
+			p.advance()
+		}
+
+		n, err := strconv.Atoi(p.src[start:p.pos])
+		if err != nil || n > 255 {
+			return p.errorf("invalid decimal escape")
+		}
+
+		b.WriteByte(byte(n))
+	}
+
+	return nil
+}
+
+// longBracketLevel reports whether the input starts a long bracket such as
+// [[ or [==[ and returns its level, the number of = signs.
+func (p *parser) longBracketLevel() (int, bool) {
+	rest := p.src[p.pos:]
+	if !strings.HasPrefix(rest, "[") {
+		return 0, false
+	}
+
+	level := 0
+	for 1+level < len(rest) && rest[1+level] == '=' {
+		level++
+	}
+
+	if 1+level >= len(rest) || rest[1+level] != '[' {
+		return 0, false
+	}
+
+	return level, true
+}
+
+func (p *parser) longString(level int) (string, error) {
+	p.pos += level + 2
+	// a newline directly after the opening bracket is skipped
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.advance()
+	}
+	if p.peek() == '\n' {
+		p.advance()
+	}
+
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(p.src[p.pos:], closing)
+	if end < 0 {
+		p.pos = len(p.src)
+		return "", p.errorf("unfinished long string")
+	}
+
+	s := p.src[p.pos : p.pos+end]
+	// advance one byte at a time to keep line numbers right
+	target := p.pos + end
+	for p.pos < target {
+		p.advance()
+	}
+	p.pos += len(closing)
+
+	return s, nil
+}
@@ -0,0 +1,141 @@
+package lua_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jbweber/gringotts-bot/internal/lua"
+	"github.com/stretchr/testify/require"
+)
+
+var savedVariables = `
+-- written by the game
+BankItems_Save = {
+	["Gbank|Mankrik"] = {
+		[1] = {
+			["link"] = "|cffffffff|Hitem:2589::::::::60:::::::|h[Linen Cloth]|h|r",
+			["count"] = 20,
+		},
+		["money"] = 1234567,
+		["faction"] = 'Horde',
+		["Bag0"] = {
+			{ link = "item", count = 1 }, -- list entry
+			{ link = "other"; count = 0x10 };
+		},
+	},
+}
+GringottsExporterDB = nil
+Other = { true, false, nil, -1.5e2, "a\tb\\\"\65\x42", [[long
+string]], [==[with ]] inside]==] }
+--[[ a long
+comment ]]
+Last = 3;
+`
+
+func TestParseSavedVariables(t *testing.T) {
+	vars, err := lua.ParseSavedVariables([]byte(savedVariables))
+	require.NoError(t, err)
+	require.Len(t, vars, 4)
+	require.Nil(t, vars["GringottsExporterDB"])
+	require.Equal(t, 3.0, vars["Last"])
+
+	save := vars["BankItems_Save"].(*lua.Table)
+	char := save.Table("Gbank|Mankrik")
+	require.NotNil(t, char)
+	require.Equal(t, "|cffffffff|Hitem:2589::::::::60:::::::|h[Linen Cloth]|h|r", char.Table(1).String("link"))
+	require.Equal(t, 20.0, char.Table(1).Number("count"))
+	require.Equal(t, 1234567.0, char.Number("money"))
+	require.Equal(t, "Horde", char.String("faction"))
+
+	bag := char.Table("Bag0")
+	require.Equal(t, 2, bag.Len())
+	require.Equal(t, "item", bag.Table(1).String("link"))
+	require.Equal(t, 16.0, bag.Table(2).Number("count"))
+
+	var keys []any
+	for _, f := range char.Fields() {
+		keys = append(keys, f.Key)
+	}
+	require.Equal(t, []any{1.0, "money", "faction", "Bag0"}, keys)
+
+	other := vars["Other"].(*lua.Table)
+	require.Equal(t, true, other.Get(1))
+	require.Equal(t, false, other.Get(2))
+	require.Nil(t, other.Get(3))
+	require.Equal(t, -150.0, other.Get(4))
+	require.Equal(t, "a\tb\\\"AB", other.Get(5))
+	require.Equal(t, "long\nstring", other.Get(6))
+	require.Equal(t, "with ]] inside", other.Get(7))
+	require.Equal(t, 2, other.Len())
+}
+
+func TestParseSavedVariables_Errors(t *testing.T) {
+	for _, input := range []string{
+		`X = `,
+		`X = { 1, 2`,
+		`X = "unfinished`,
+		`X = os.exit()`,
+		`X = function() end`,
+		`X = { [nil] = 1 }`,
+		`= 1`,
+		`X = [[never closed`,
+		`X = "\q"`,
+	} {
+		_, err := lua.ParseSavedVariables([]byte(input))
+		require.Error(t, err, input)
+	}
+
+	_, err := lua.ParseSavedVariables([]byte("X = {\n  1,\n  @\n}"))
+	var syntaxErr *lua.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	require.Equal(t, 3, syntaxErr.Line)
+	require.Equal(t, 3, syntaxErr.Column)
+}
+
+func TestParseValue(t *testing.T) {
+	v, err := lua.ParseValue([]byte(`{ a = { b = "c" } }`))
+	require.NoError(t, err)
+	require.Equal(t, "c", v.(*lua.Table).Table("a").String("b"))
+
+	_, err = lua.ParseValue([]byte(`1 2`))
+	require.Error(t, err)
+
+	v, err = lua.ParseValue([]byte(`- - 1`))
+	require.NoError(t, err)
+	require.Equal(t, 1.0, v)
+
+	// negations count towards the nesting limit like tables do
+	_, err = lua.ParseValue([]byte(strings.Repeat("- ", 1000) + "1"))
+	require.ErrorContains(t, err, "nested deeper")
+}
+
+func TestTable_Set(t *testing.T) {
+	tbl := lua.NewTable()
+	for k := 1; k <= 10; k++ {
+		tbl.Set(float64(k), fmt.Sprint(k))
+	}
+
+	// removing keeps the remaining fields in order, whether or not the
+	// removed ones were compacted away yet
+	for _, k := range []int{2, 4, 6, 8, 10, 9} {
+		tbl.Set(float64(k), nil)
+		require.Nil(t, tbl.Get(k))
+	}
+
+	require.Equal(t, "5", tbl.Get(5))
+	require.Equal(t, 1, tbl.Len())
+
+	var keys []any
+	for _, f := range tbl.Fields() {
+		keys = append(keys, f.Key)
+	}
+	require.Equal(t, []any{1.0, 3.0, 5.0, 7.0}, keys)
+
+	tbl.Set(2.0, "again")
+	tbl.Set(7.0, "seven")
+	require.Equal(t, "seven", tbl.Get(7))
+	require.Equal(t, 3, tbl.Len())
+	require.Len(t, tbl.Fields(), 5)
+	require.Equal(t, 2.0, tbl.Fields()[4].Key)
+}
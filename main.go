@@ -1,88 +1,173 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
-	"os/signal"
 
-	"github.com/bwmarrin/discordgo"
 	"github.com/jbweber/gringotts-bot/internal/bot/interactions"
+	"github.com/jbweber/gringotts-bot/internal/config"
 	"github.com/jbweber/gringotts-bot/internal/database"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-const (
-	appID    = "APP_ID"
-	botToken = "BOT_TOKEN"
-	dbPath   = "DB_PATH"
-	serverID = "SERVER_ID"
-)
+// command is a subcommand of the gringotts-bot binary. run is passed the
+// arguments following the command name.
+type command struct {
+	name        string
+	description string
+	run         func(args []string, out io.Writer) error
+}
+
+var commands = []*command{
+	{name: "serve", description: "connect to Discord and handle interactions, the default", run: runServe},
+	{name: "migrate", description: "apply database migrations or show their status", run: runMigrate},
+	{name: "import", description: "load inventory files into the database", run: runImport},
+	{name: "export", description: "write the current inventories as CSV, exporter payloads or JSON", run: runExport},
+	{name: "catalog", description: "load item metadata dumps into the item catalog", run: runCatalog},
+	{name: "search", description: "search the bank for items by name", run: runSearch},
+	{name: "register-commands", description: "register the slash commands with Discord", run: runRegisterCommands},
+	{name: "unregister-commands", description: "remove the slash commands from Discord", run: runUnregisterCommands},
+	{name: "config", description: "check the configuration for problems", run: runConfig},
+}
 
 func main() {
-	theAppID, ok := os.LookupEnv(appID)
-	if !ok {
-		log.Fatal("unable to lookup appID")
+	args := os.Args[1:]
+	if len(args) == 0 {
+		// running without a command serves, as before subcommands existed
+		args = []string{"serve"}
 	}
 
-	theBotToken, ok := os.LookupEnv(botToken)
-	if !ok {
-		log.Fatal("unable to lookup bot token")
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(os.Stdout)
+		return
 	}
 
-	theServerID, ok := os.LookupEnv(serverID)
-	if !ok {
-		log.Fatal("unable to lookup serverID")
-	}
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+
+		err := c.run(args[1:], os.Stdout)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+
+		if err != nil {
+			// not logged, the default logger is replaced once the
+			// configuration is loaded
+			_, _ = fmt.Fprintf(os.Stderr, "gringotts-bot %s: %v\n", c.name, err)
+			os.Exit(1)
+		}
 
-	theDBPath, ok := os.LookupEnv(dbPath)
-	if !ok {
-		log.Fatalf("unable to lookup dbPath")
+		return
 	}
 
-	s, _ := discordgo.New("Bot " + theBotToken)
+	usage(os.Stderr)
+	os.Exit(2)
+}
 
-	registeredCommands, err := s.ApplicationCommandBulkOverwrite(theAppID, theServerID, interactions.Commands)
-	if err != nil {
-		log.Fatalf("error registering commands, %v", err)
+func usage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "usage: gringotts-bot <command> [arguments]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(w, "  %-20s %s\n", c.name, c.description)
 	}
+}
 
-	db, err := database.NewDB(theDBPath)
+// loadConfig loads the configuration after the flags in cf are parsed and
+// sets up logging. Commands connecting to Discord pass discord to validate the
+// settings they need as well.
+func loadConfig(cf *config.Flags, discord bool) (*config.Config, error) {
+	cfg, err := cf.Load()
 	if err != nil {
-		log.Fatalf("error creating Database: %v", err)
+		return nil, err
 	}
 
-	defer func() { _ = db.Close() }()
+	validate := cfg.Validate
+	if discord {
+		validate = cfg.ValidateDiscord
+	}
 
-	migrator := database.NewMigrator(db)
-	err = migrator.Migrate()
-	if err != nil {
-		log.Fatalf("error migrating Database: %v", err)
+	if err := validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
 	}
 
-	g := database.NewGringotts(db)
+	setupLogging(os.Stderr, cfg.Level())
+
+	return cfg, nil
+}
 
-	h := interactions.NewHandler(g)
+// setupLogging sends slog records of at least level to w. The log package,
+// which discordgo reports its own errors with, keeps writing to w directly so
+// the level does not drop them.
+func setupLogging(w io.Writer, level slog.Level) {
+	flags := log.Flags()
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
 
-	s.AddHandler(h.Handle)
+	// SetDefault routes the log package through the handler at info level
+	log.SetOutput(w)
+	log.SetFlags(flags)
+}
 
-	err = s.Open()
+// openDB opens the configured database without migrating it.
+func openDB(cfg *config.Config) (*sql.DB, error) {
+	db, err := database.NewDB(cfg.DBPath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("error creating Database: %w", err)
 	}
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
-	log.Println("Press Ctrl+C to exit")
-	<-stop
 
-	for _, v := range registeredCommands {
-		err := s.ApplicationCommandDelete(s.State.User.ID, theServerID, v.ID)
-		if err != nil {
-			log.Printf("error deleting command %s:%s, %v", v.ID, v.Name, err)
-		}
+	return db, nil
+}
+
+// openGringotts opens and migrates the configured database. The returned
+// function closes it.
+func openGringotts(cfg *config.Config) (*database.Gringotts, func(), error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	err = s.Close()
+	err = database.NewMigrator(db).Migrate()
 	if err != nil {
-		log.Fatal(err)
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("error migrating Database: %w", err)
+	}
+
+	return database.NewGringotts(db), func() { _ = db.Close() }, nil
+}
+
+// addGuildFlag registers the -guild-id flag selecting the server whose bank
+// offline commands work on.
+func addGuildFlag(fs *flag.FlagSet) *string {
+	return fs.String("guild-id", "", "server whose bank to use, defaults to the only configured server")
+}
+
+// dataGuild returns the server offline commands work on, the -guild-id flag or
+// the only configured server.
+func dataGuild(cfg *config.Config, guildID string) (string, error) {
+	if guildID != "" {
+		return guildID, nil
+	}
+
+	if len(cfg.GuildIDs) != 1 {
+		return "", errors.New("-guild-id is required unless exactly one server is configured")
+	}
+
+	return cfg.GuildIDs[0], nil
+}
+
+// features converts the configured feature toggles for the handler.
+func features(cfg *config.Config) interactions.Features {
+	return interactions.Features{
+		Autocomplete:      cfg.Features.Autocomplete,
+		SearchSuggestions: cfg.Features.SearchSuggestions,
+		AuditLog:          cfg.Features.AuditLog,
 	}
 }
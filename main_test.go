@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupLogging(t *testing.T) {
+	defaultLogger, flags := slog.Default(), log.Flags()
+	defer func() {
+		slog.SetDefault(defaultLogger)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+
+	var out bytes.Buffer
+	setupLogging(&out, slog.LevelError)
+
+	slog.Info("connected")
+	slog.Warn("unable to update request message")
+	log.Printf("[DG0] error occurred, %v", "boom")
+	slog.Error("unable to open database")
+
+	require.NotContains(t, out.String(), "connected")
+	require.NotContains(t, out.String(), "unable to update request message")
+	require.Contains(t, out.String(), "error occurred, boom")
+	require.Contains(t, out.String(), "unable to open database")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jbweber/gringotts-bot/internal/config"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// runMigrate applies pending migrations with "up", lists them with "status" or
+// assigns data stored before it was kept per server with "assign-guild".
+func runMigrate(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	guildFlag := addGuildFlag(fs)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "usage: gringotts-bot migrate up|status|assign-guild [flags]")
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 || (args[0] != "up" && args[0] != "status" && args[0] != "assign-guild") {
+		fs.Usage()
+		return errors.New("expected up, status or assign-guild")
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cf, false)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = db.Close() }()
+
+	m := database.NewMigrator(db)
+
+	switch args[0] {
+	case "assign-guild":
+		guildID, err := dataGuild(cfg, *guildFlag)
+		if err != nil {
+			return err
+		}
+
+		if err := m.Migrate(); err != nil {
+			return fmt.Errorf("error migrating Database: %w", err)
+		}
+
+		assigned, err := database.NewGringotts(db).AssignGuild(context.Background(), guildID)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(out, "assigned %d row(s) to %s\n", assigned, guildID)
+
+		return nil
+	case "up":
+		before, err := m.GetLatestMigrationID()
+		if err != nil && err.Error() != "no such table: migration" {
+			return err
+		}
+
+		if err := m.Migrate(); err != nil {
+			return fmt.Errorf("error migrating Database: %w", err)
+		}
+
+		after, err := m.GetLatestMigrationID()
+		if err != nil {
+			return err
+		}
+
+		if after == before {
+			_, _ = fmt.Fprintf(out, "already at migration %d\n", after)
+		} else {
+			_, _ = fmt.Fprintf(out, "migrated to %d\n", after)
+		}
+
+		return nil
+	case "status":
+		status, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tSTATUS\tAPPLIED AT")
+		for _, s := range status {
+			state, at := "pending", ""
+			if s.Applied {
+				state, at = "applied", s.AppliedAt.Format(time.DateTime)
+			}
+
+			if _, ok := database.Migrations[s.ID]; !ok {
+				state += " (unknown)"
+			}
+
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", s.ID, state, at)
+		}
+
+		return w.Flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/jbweber/gringotts-bot/internal/bot/interactions"
+	"github.com/jbweber/gringotts-bot/internal/config"
+)
+
+// runRegisterCommands registers the slash commands in every configured server,
+// or globally, without serving, replacing any registered before.
+func runRegisterCommands(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("register-commands", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cf, true)
+	if err != nil {
+		return err
+	}
+
+	s, err := newSession(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, guildID := range cfg.CommandGuildIDs() {
+		registered, err := s.ApplicationCommandBulkOverwrite(cfg.AppID, guildID, interactions.CommandsFor(features(cfg)))
+		if err != nil {
+			return fmt.Errorf("error registering commands, %w", err)
+		}
+
+		for _, c := range registered {
+			_, _ = fmt.Fprintf(out, "registered %s (%s) in %s\n", c.Name, c.ID, guildName(guildID))
+		}
+	}
+
+	return nil
+}
+
+// guildName describes where commands registered under guildID apply.
+func guildName(guildID string) string {
+	if guildID == "" {
+		return "every server"
+	}
+
+	return guildID
+}
+
+// runUnregisterCommands removes every slash command registered for the
+// application in the configured servers, or globally.
+func runUnregisterCommands(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("unregister-commands", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cf, true)
+	if err != nil {
+		return err
+	}
+
+	s, err := newSession(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, guildID := range cfg.CommandGuildIDs() {
+		registered, err := s.ApplicationCommands(cfg.AppID, guildID)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range registered {
+			err := s.ApplicationCommandDelete(cfg.AppID, guildID, c.ID)
+			if err != nil {
+				return fmt.Errorf("error deleting command %s:%s, %w", c.ID, c.Name, err)
+			}
+
+			_, _ = fmt.Fprintf(out, "unregistered %s (%s) in %s\n", c.Name, c.ID, guildName(guildID))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jbweber/gringotts-bot/internal/config"
+	"github.com/jbweber/gringotts-bot/internal/database"
+)
+
+// runSearch searches the bank the same way /gbank search does.
+func runSearch(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	guildFlag := addGuildFlag(fs)
+	limit := fs.Int("limit", 25, "maximum number of items to show")
+	minQualityFlag := fs.String("min-quality", "", "only show items of at least this quality, by name such as rare or number")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "usage: gringotts-bot search [-limit n] [-min-quality q] <term>")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("a search term is required")
+	}
+
+	minQuality, err := parseMinQuality(*minQualityFlag)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cf, false)
+	if err != nil {
+		return err
+	}
+
+	guildID, err := dataGuild(cfg, *guildFlag)
+	if err != nil {
+		return err
+	}
+
+	g, closeDB, err := openGringotts(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer closeDB()
+
+	result, err := g.SearchItems(context.Background(), guildID, strings.Join(fs.Args(), " "), minQuality, *limit, 0)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Items) == 0 {
+		if len(result.Suggestions) > 0 {
+			_, _ = fmt.Fprintf(out, "no items found, did you mean: %s\n", strings.Join(result.Suggestions, ", "))
+		} else {
+			_, _ = fmt.Fprintln(out, "no items found")
+		}
+
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tQUALITY\tCOUNT\tHOLDERS")
+	for _, item := range result.Items {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", item.ID, item.Name, database.QualityName(item.Quality), item.Count, holderList(item.Holders))
+	}
+
+	return w.Flush()
+}
+
+// parseMinQuality reads the -min-quality flag, a quality name or number. Empty
+// means any quality.
+func parseMinQuality(s string) (int, error) {
+	if s == "" {
+		return database.QualityPoor, nil
+	}
+
+	if q, ok := database.ParseQuality(s); ok {
+		return q, nil
+	}
+
+	q, err := strconv.Atoi(s)
+	if err != nil || q < database.QualityPoor || q > database.QualityHeirloom {
+		return 0, fmt.Errorf("invalid quality %q", s)
+	}
+
+	return q, nil
+}
+
+// holderList lists holders with their realm and count.
+func holderList(holders []*database.Holder) string {
+	var list []string
+	for _, h := range holders {
+		list = append(list, fmt.Sprintf("%s %d", h.Owner, h.Count))
+	}
+
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jbweber/gringotts-bot/internal/bot/interactions"
+	"github.com/jbweber/gringotts-bot/internal/config"
+	"github.com/jbweber/gringotts-bot/internal/itemlink"
+)
+
+// runServe connects to Discord and handles interactions until interrupted.
+// Commands are registered on startup and removed on shutdown unless
+// -keep-commands is set.
+func runServe(args []string, _ io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cf := config.RegisterFlags(fs)
+	keepCommands := fs.Bool("keep-commands", false, "leave the slash commands registered on shutdown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cf, true)
+	if err != nil {
+		return err
+	}
+
+	s, err := newSession(cfg)
+	if err != nil {
+		return err
+	}
+
+	registeredCommands := map[string][]*discordgo.ApplicationCommand{}
+	for _, guildID := range cfg.CommandGuildIDs() {
+		registered, err := s.ApplicationCommandBulkOverwrite(cfg.AppID, guildID, interactions.CommandsFor(features(cfg)))
+		if err != nil {
+			return fmt.Errorf("error registering commands, %w", err)
+		}
+
+		registeredCommands[guildID] = registered
+	}
+
+	g, closeDB, err := openGringotts(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer closeDB()
+
+	if len(cfg.GuildIDs) == 1 {
+		// a single server owns everything stored before data was kept per
+		// server
+		assigned, err := g.AssignGuild(context.Background(), cfg.GuildIDs[0])
+		if err != nil {
+			return err
+		}
+
+		if assigned > 0 {
+			slog.Info("assigned existing data to server", "guild", cfg.GuildIDs[0], "rows", assigned)
+		}
+	}
+
+	h := interactions.NewHandler(g,
+		interactions.WithDefaultCapabilities(cfg.Permissions.DefaultCapabilities),
+		interactions.WithFeatures(features(cfg)),
+		interactions.WithItemLinks(itemlink.New(cfg.ItemLinkSite, cfg.WowheadFlavor, cfg.GuildFlavors)),
+		interactions.WithRequests(cfg.Requests.Channels, cfg.Requests.AutoFulfill),
+	)
+
+	s.AddHandler(h.Handle)
+
+	err = s.Open()
+	if err != nil {
+		return err
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	slog.Info("running, press Ctrl+C to exit")
+	<-stop
+
+	if !*keepCommands {
+		for guildID, registered := range registeredCommands {
+			for _, v := range registered {
+				err := s.ApplicationCommandDelete(s.State.User.ID, guildID, v.ID)
+				if err != nil {
+					slog.Error("unable to delete command", "id", v.ID, "name", v.Name, "err", err)
+				}
+			}
+		}
+	}
+
+	return s.Close()
+}
+
+// newSession creates a Discord session for the configured bot.
+func newSession(cfg *config.Config) (*discordgo.Session, error) {
+	return discordgo.New("Bot " + cfg.Token)
+}